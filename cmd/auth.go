@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"strings"
+	"ticktick-tui/internal/auth"
+	"ticktick-tui/internal/client"
 	"ticktick-tui/internal/core"
+	"ticktick-tui/internal/secrets"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var authCmd = &cobra.Command{
@@ -17,56 +24,209 @@ var authCmd = &cobra.Command{
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "生成OAuth授权URL",
-	Long:  `生成OAuth授权URL，用户需要在浏览器中打开此URL进行授权。`,
+	Short: "登录TickTick账号",
+	Long: `生成OAuth授权URL并在浏览器中打开。
+
+如果redirect_uri指向本机（如 http://localhost:8080/callback），会在该地址临时启动
+监听服务自动获取授权码并保存访问令牌；否则或超时后可以手动粘贴浏览器跳转到的完整URL。`,
 	Run: func(cmd *cobra.Command, args []string) {
-		clientID := viper.GetString("client_id")
-		redirectURI := viper.GetString("redirect_uri")
+		profile := core.CurrentProfile()
+		clientSecret, _ := core.GetSecret("client_secret")
 
-		if clientID == "" || redirectURI == "" {
-			fmt.Println("错误：请先配置client_id和redirect_uri")
+		if profile.ClientID == "" || clientSecret == "" || profile.RedirectURI == "" {
+			fmt.Println("错误：请先配置client_id、client_secret和redirect_uri")
 			os.Exit(1)
 		}
 
-		authURL := core.GetAuthURL()
+		noBrowser, _ := cmd.Flags().GetBool("no-browser")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		authURL, err := core.GetAuthURL()
+		if err != nil {
+			fmt.Printf("生成授权URL失败：%v\n", err)
+			os.Exit(1)
+		}
 		fmt.Println("请在浏览器中打开以下URL进行授权：")
 		fmt.Println(authURL)
-		fmt.Println("\n授权完成后，从重定向URL中复制授权码，然后运行：")
-		fmt.Println("ticktick-tui auth token <authorization_code>")
+
+		if !noBrowser {
+			if err := auth.OpenBrowser(authURL); err == nil {
+				fmt.Println("已在浏览器中打开授权页面")
+			}
+		}
+
+		var code, state string
+		server, err := core.ListenForCallback()
+		if err != nil {
+			fmt.Printf("\n无法自动获取授权码：%v\n", err)
+		} else {
+			fmt.Printf("\n正在等待授权回调（%s后超时）...\n", timeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			code, state, err = server.Wait(ctx)
+			cancel()
+			if err != nil {
+				fmt.Printf("自动获取授权码失败：%v\n", err)
+			}
+		}
+
+		if code == "" {
+			code, state = core.ParseAuthResponse(promptAuthCode())
+		}
+
+		if _, err := core.Login(code, state); err != nil {
+			fmt.Printf("获取访问令牌失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("登录成功，访问令牌已保存到配置文件！")
 	},
 }
 
+// promptAuthCode falls back to asking for the redirect URL or the code in it.
+// Without a terminal it explains the two-step flow and exits instead.
+func promptAuthCode() string {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println("\n授权完成后，复制浏览器跳转到的完整URL，然后运行：")
+		fmt.Println("ticktick-tui auth token '<redirect_url>'")
+		os.Exit(1)
+	}
+
+	fmt.Print("\n请粘贴浏览器跳转到的完整URL：")
+	code, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	code = strings.TrimSpace(code)
+	if code == "" {
+		fmt.Println("错误：URL不能为空")
+		os.Exit(1)
+	}
+	return code
+}
+
 var tokenCmd = &cobra.Command{
-	Use:   "token <authorization_code>",
+	Use:   "token <redirect_url>",
 	Short: "使用授权码获取访问令牌",
-	Long:  `使用从OAuth流程获得的授权码来获取访问令牌。`,
-	Args:  cobra.ExactArgs(1),
+	Long: `使用浏览器跳转到的完整URL中的授权码来获取访问令牌。
+
+URL中的state参数会与进行中的登录比对以防止CSRF攻击，因此不接受单独的授权码。
+必须先运行 auth login 生成授权URL，登录会话30分钟内有效。`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		clientID := viper.GetString("client_id")
-		clientSecret := viper.GetString("client_secret")
-		redirectURI := viper.GetString("redirect_uri")
+		profile := core.CurrentProfile()
+		clientSecret, _ := core.GetSecret("client_secret")
 
-		if clientID == "" || clientSecret == "" || redirectURI == "" {
+		if profile.ClientID == "" || clientSecret == "" || profile.RedirectURI == "" {
 			fmt.Println("错误：请先配置client_id、client_secret和redirect_uri")
 			os.Exit(1)
 		}
 
-		code := args[0]
+		code, state := core.ParseAuthResponse(args[0])
 
-		token, err := core.GetToken(code)
+		token, err := core.Login(code, state)
 		if err != nil {
 			fmt.Printf("获取访问令牌失败：%v\n", err)
 			os.Exit(1)
 		}
 
-		viper.Set("access_token", token.AccessToken)
-		if err := viper.WriteConfig(); err != nil {
-			fmt.Printf("保存配置失败：%v\n", err)
+		fmt.Println("访问令牌获取成功并已保存！")
+		fmt.Printf("访问令牌：%s\n", secrets.Redact(token.AccessToken))
+	},
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看认证状态",
+	Long:  `显示当前访问令牌的权限范围、剩余有效期以及是否可以自动刷新，并通过API验证令牌是否有效。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Tokens may be in the encrypted secrets file
+		if err := core.UnlockSecrets(); err != nil {
+			fmt.Println("错误：", err)
+			os.Exit(1)
+		}
+
+		info := core.GetTokenInfo()
+		if !info.HasToken {
+			fmt.Println("未登录，请运行 'ticktick-tui auth login' 进行身份验证")
 			os.Exit(1)
 		}
 
-		fmt.Println("访问令牌获取成功并已保存到配置文件！")
-		fmt.Printf("访问令牌：%s\n", token.AccessToken)
+		scope := info.Scope
+		if scope == "" {
+			scope = "未知"
+		}
+		fmt.Printf("权限范围：%s\n", scope)
+
+		switch {
+		case info.ExpiresAt.IsZero():
+			fmt.Println("有效期：未知")
+		case info.Expired():
+			fmt.Printf("有效期：已于 %s 过期\n", info.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		default:
+			remaining := time.Until(info.ExpiresAt)
+			fmt.Printf("有效期：%s 到期（剩余 %d 天 %d 小时）\n",
+				info.ExpiresAt.Local().Format("2006-01-02 15:04"),
+				int(remaining.Hours())/24, int(remaining.Hours())%24)
+		}
+
+		if info.HasRefreshToken {
+			fmt.Println("自动刷新：可用")
+		} else {
+			fmt.Println("自动刷新：不可用（过期后需要重新登录）")
+		}
+
+		if _, err := core.NewClient().GetProjects(); err != nil {
+			if client.IsUnauthorized(err) {
+				fmt.Println("令牌状态：无效，请运行 'ticktick-tui auth login' 重新登录")
+			} else {
+				fmt.Printf("令牌状态：无法验证（%v）\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("令牌状态：有效")
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "退出登录",
+	Long: `删除当前配置档案的访问令牌和刷新令牌，并清除该账号的本地缓存。
+OAuth凭据（client_id、client_secret）会保留，之后可以直接运行 auth login 重新登录。
+
+使用 --all 退出所有配置档案。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+
+		profiles := []string{core.ActiveProfile()}
+		prompt := fmt.Sprintf("确认退出配置档案 %s？", core.ActiveProfile())
+		if all {
+			profiles = core.Profiles()
+			prompt = "确认退出所有配置档案？"
+		}
+
+		if !confirmAction(prompt) {
+			fmt.Println("已取消")
+			return
+		}
+		if dryRun {
+			fmt.Printf("[dry-run] 将删除以下配置档案的令牌和缓存：%s\n", strings.Join(profiles, ", "))
+			return
+		}
+
+		if err := core.UnlockSecrets(); err != nil {
+			fmt.Println("错误：", err)
+			os.Exit(1)
+		}
+		for _, profile := range profiles {
+			if err := core.Logout(profile); err != nil {
+				fmt.Printf("退出配置档案 %s 失败：%v\n", profile, err)
+				os.Exit(1)
+			}
+			fmt.Printf("已退出配置档案：%s\n", profile)
+		}
+
+		// Tokens from the environment or a command can't be removed here
+		if source := core.SecretSource("access_token"); source != "" {
+			fmt.Printf("注意：访问令牌仍由 %s 提供（%s 或 access_token_cmd），需要手动移除\n",
+				source, core.SecretEnvVar("access_token"))
+		}
 	},
 }
 
@@ -74,4 +234,10 @@ func init() {
 	rootCmd.AddCommand(authCmd)
 	authCmd.AddCommand(loginCmd)
 	authCmd.AddCommand(tokenCmd)
+	authCmd.AddCommand(statusCmd)
+	authCmd.AddCommand(logoutCmd)
+
+	loginCmd.Flags().Bool("no-browser", false, "不自动打开浏览器")
+	loginCmd.Flags().Duration("timeout", 3*time.Minute, "等待授权回调的时间")
+	logoutCmd.Flags().Bool("all", false, "退出所有配置档案")
 }
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
 
 	"ticktick-tui/internal/core"
 
@@ -19,47 +27,248 @@ var configCmd = &cobra.Command{
 var setCmd = &cobra.Command{
 	Use:   "set <key> <value>",
 	Short: "设置配置值",
-	Long:  `设置配置键值对。包括client_id、client_secret、redirect_uri。`,
-	Args:  cobra.ExactArgs(2),
+	Long: `设置配置键值对。包括client_id、client_secret、redirect_uri、region等。
+
+凭据、令牌和区域保存在当前配置档案中，其他配置由所有配置档案共享。
+保存前会校验配置项名称和取值。`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
 		value := args[1]
 
+		if dryRun {
+			if err := core.CheckSetting(key, value); err != nil {
+				fmt.Fprintf(os.Stderr, "无法保存配置：%v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("[dry-run] 将设置配置项：%s = %s\n", key, core.RedactSetting(key, value))
+			return
+		}
+
 		if err := core.SaveConfig(key, value); err != nil {
 			fmt.Fprintf(os.Stderr, "无法保存配置：%v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("配置已设置：%s = %s\n", key, value)
+		fmt.Printf("配置已设置：%s = %s\n", key, core.RedactSetting(key, value))
 	},
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "列出所有配置",
-	Long:  `列出当前所有的配置键值对。`,
+	Long:  `列出共享配置以及当前配置档案的配置键值对。`,
 	Run: func(cmd *cobra.Command, args []string) {
-		settings := viper.AllSettings()
+		if err := core.UnlockSecrets(); err != nil {
+			fmt.Fprintln(os.Stderr, "警告：", err)
+		}
+
+		var keys []string
+		for _, key := range viper.AllKeys() {
+			// Profile settings are listed below, other profiles by 'profile list'
+			if !strings.HasPrefix(key, "profiles.") && !slices.Contains(core.ProfileKeys(), key) && viper.IsSet(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		fmt.Println("共享配置：")
+		for _, key := range keys {
+			printSetting(key, viper.GetString(key))
+		}
+
+		fmt.Printf("\n配置档案 %s：\n", core.ActiveProfile())
+		for _, key := range core.ProfileKeys() {
+			if !core.IsSet(key) {
+				continue
+			}
+			if core.IsSecretKey(key) {
+				// Show where the secret comes from, never the value
+				fmt.Printf("  %s = %s (%s)\n", key, core.RedactSetting(key, core.GetString(key)), core.SecretSource(key))
+			} else {
+				printSetting(key, core.GetString(key))
+			}
+		}
+	},
+}
 
-		if len(settings) == 0 {
-			fmt.Println("没有找到配置")
+var getCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "查看配置值",
+	Long:  `显示一个配置项的值。凭据只显示部分内容。`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+		if !core.IsKnownKey(key) {
+			fmt.Fprintf(os.Stderr, "未知的配置项：%s\n", key)
+			os.Exit(1)
+		}
+
+		value := core.GetString(key)
+		if core.IsSecretKey(key) {
+			var err error
+			if value, err = core.GetSecret(key); err != nil {
+				fmt.Fprintf(os.Stderr, "读取凭据失败：%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if value == "" {
+			os.Exit(1)
+		}
+		fmt.Println(core.RedactSetting(key, value))
+	},
+}
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "删除配置值",
+	Long:  `从配置文件中删除一个配置项，之后使用默认值。`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			if !core.IsKnownKey(args[0]) {
+				fmt.Fprintf(os.Stderr, "未知的配置项：%s\n", args[0])
+				os.Exit(1)
+			}
+			fmt.Printf("[dry-run] 将删除配置项 %s\n", args[0])
 			return
 		}
 
-		fmt.Println("当前配置：")
-		for key, value := range settings {
-			// 隐藏敏感信息
-			if key == "access_token" || key == "client_secret" {
-				fmt.Printf("%s = ***\n", key)
+		if err := core.UnsetConfig(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "无法删除配置：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("配置已删除：%s\n", args[0])
+	},
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "使用编辑器修改配置文件",
+	Long: `使用 $VISUAL 或 $EDITOR 打开配置文件的副本。保存后会先校验，
+只有校验通过才会替换配置文件。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		original, err := os.ReadFile(core.ConfigFile())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "无法读取配置文件：%v\n", err)
+			os.Exit(1)
+		}
+
+		tmp, err := os.CreateTemp("", "ticktick-tui-*"+filepath.Ext(core.ConfigFile()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法创建临时文件：%v\n", err)
+			os.Exit(1)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(original)
+		tmp.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法创建临时文件：%v\n", err)
+			os.Exit(1)
+		}
+
+		for {
+			if err := runEditor(tmp.Name()); err != nil {
+				fmt.Fprintf(os.Stderr, "编辑器退出异常：%v\n", err)
+				os.Exit(1)
+			}
+
+			edited, err := os.ReadFile(tmp.Name())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "无法读取临时文件：%v\n", err)
+				os.Exit(1)
+			}
+			if bytes.Equal(edited, original) {
+				fmt.Println("配置未修改")
+				return
+			}
+
+			if dryRun {
+				// Validate as usual but leave the configuration file alone
+				err = core.ValidateConfigFile(tmp.Name())
+				if err == nil {
+					fmt.Printf("[dry-run] 将用修改后的内容替换配置文件 %s\n", core.ConfigFile())
+					return
+				}
 			} else {
-				fmt.Printf("%s = %v\n", key, value)
+				err = core.InstallConfigFile(tmp.Name())
+				if err == nil {
+					fmt.Println("配置已保存")
+					return
+				}
 			}
+
+			fmt.Println("配置无效：")
+			printValidationErrors(err)
+			if !confirm("重新编辑？") {
+				fmt.Println("已放弃修改")
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "校验配置文件",
+	Long:  `检查配置文件中的未知配置项和无效值。发现问题时以非零状态退出，可以在CI中使用。`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if len(args) == 1 {
+			err = core.ValidateConfigFile(args[0])
+		} else {
+			err = core.ValidateConfig()
+		}
+
+		if err != nil {
+			fmt.Println("配置无效：")
+			printValidationErrors(err)
+			os.Exit(1)
 		}
+		fmt.Println("配置有效")
 	},
 }
 
+// runEditor opens path in the user's editor and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may come with arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+func printValidationErrors(err error) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+func printSetting(key, value string) {
+	fmt.Printf("  %s = %s\n", key, core.RedactSetting(key, value))
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(setCmd)
 	configCmd.AddCommand(listCmd)
+	configCmd.AddCommand(getCmd)
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(editCmd)
+	configCmd.AddCommand(validateCmd)
 }
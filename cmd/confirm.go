@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"ticktick-tui/internal/client"
+
+	"golang.org/x/term"
+)
+
+var (
+	dryRun    bool
+	assumeYes bool
+)
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// confirmAction asks before a destructive operation. It only prompts when
+// attached to a terminal, so scripts are never blocked; --yes and --dry-run
+// skip the prompt entirely.
+func confirmAction(prompt string) bool {
+	if assumeYes || dryRun || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return true
+	}
+	return confirm(prompt)
+}
+
+// isDryRun reports whether err only means that the request was not sent
+// because of --dry-run
+func isDryRun(err error) bool {
+	return errors.Is(err, client.ErrDryRun)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"ticktick-tui/internal/exporter"
+	"ticktick-tui/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出任务",
+	Long: `将任务导出为JSON、todo.txt、Markdown、org-mode或iCalendar（VTODO）格式。
+
+默认导出收集箱和所有未归档项目，使用 --project 可以只导出指定项目（收集箱为 inbox）。
+相同的数据总是生成相同的输出，todo.txt和Markdown格式可以通过 tasks import 重新导入。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+
+		format, _ := cmd.Flags().GetString("format")
+		projectIDs, _ := cmd.Flags().GetStringSlice("project")
+		output, _ := cmd.Flags().GetString("output")
+
+		if !slices.Contains(exporter.Formats, exporter.Format(format)) {
+			fmt.Printf("错误：不支持的导出格式：%s\n", format)
+			os.Exit(1)
+		}
+
+		if len(projectIDs) == 0 {
+			projectIDs = append(projectIDs, models.InboxID)
+			projects, err := client.GetProjects()
+			if err != nil {
+				fmt.Printf("获取项目列表失败：%v\n", err)
+				os.Exit(1)
+			}
+			for _, project := range projects {
+				if !project.Closed {
+					projectIDs = append(projectIDs, project.ID)
+				}
+			}
+		}
+
+		var data []models.ProjectData
+		for _, projectID := range projectIDs {
+			projectData, err := client.GetProjectData(projectID)
+			if err != nil {
+				fmt.Printf("获取项目数据失败：%v\n", err)
+				os.Exit(1)
+			}
+			if projectID == models.InboxID {
+				// Exporters and the importer treat a project without a
+				// name as the Inbox
+				projectData.Project = models.Project{ID: models.InboxID}
+			}
+			data = append(data, *projectData)
+		}
+
+		var w io.Writer = os.Stdout
+		var file *os.File
+		if output != "" && output != "-" {
+			var err error
+			file, err = os.Create(output)
+			if err != nil {
+				fmt.Printf("无法创建文件：%v\n", err)
+				os.Exit(1)
+			}
+			w = file
+		}
+
+		if err := exporter.Write(w, exporter.Format(format), data); err != nil {
+			fmt.Printf("导出失败：%v\n", err)
+			os.Exit(1)
+		}
+		// Some filesystems only report write errors on close
+		if file != nil {
+			if err := file.Close(); err != nil {
+				fmt.Printf("无法写入文件：%v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("format", "f", string(exporter.FormatJSON), "导出格式（json, todotxt, markdown, org, ics）")
+	exportCmd.Flags().StringSliceP("project", "p", nil, "要导出的项目ID，可重复指定（默认为所有项目）")
+	exportCmd.Flags().StringP("output", "o", "", "输出文件（默认为标准输出）")
+}
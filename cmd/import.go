@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/importer"
+	"ticktick-tui/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var importTasksCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "从其他工具导入任务",
+	Long: `从todo.txt、Markdown清单、Todoist CSV或Taskwarrior JSON导入任务。
+
+不存在的项目会被自动创建，已存在同名任务会被跳过。
+导入前会显示预览，确认后才会提交。文件为 - 时从标准输入读取。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		defaultProject, _ := cmd.Flags().GetString("project")
+
+		var reader io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				fmt.Printf("无法打开文件：%v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			reader = file
+
+			// Todoist exports one project per file
+			if defaultProject == "" && importer.Format(format) == importer.FormatTodoistCSV {
+				defaultProject = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+		}
+
+		entries, err := importer.Parse(importer.Format(format), reader)
+		if err != nil {
+			fmt.Printf("解析文件失败：%v\n", err)
+			os.Exit(1)
+		}
+		for i := range entries {
+			if entries[i].Project == "" {
+				entries[i].Project = defaultProject
+			}
+		}
+
+		client := getClient()
+
+		plan, err := buildImportPlan(client, entries)
+		if err != nil {
+			fmt.Printf("生成导入计划失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		plan.print()
+
+		if plan.newTasks == 0 {
+			fmt.Println("没有需要导入的任务")
+			return
+		}
+
+		if !confirmAction("确认导入？") {
+			fmt.Println("已取消")
+			return
+		}
+
+		if err := plan.apply(client); err != nil {
+			fmt.Printf("导入失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			return
+		}
+		fmt.Printf("导入完成：%d 个任务，%d 个新项目\n", plan.newTasks, plan.newProjects)
+	},
+}
+
+// importProject groups the entries that go into one project
+type importProject struct {
+	name    string
+	project *models.Project // nil if the project has to be created
+	tasks   []models.Task
+	skipped []models.Task
+}
+
+type importPlan struct {
+	projects    []*importProject
+	newTasks    int
+	newProjects int
+}
+
+// buildImportPlan matches entries against existing projects and tasks.
+// Tasks whose title already exists in the target project are skipped.
+func buildImportPlan(c *client.Client, entries []importer.Entry) (*importPlan, error) {
+	existing, err := c.GetProjects()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.Project, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+
+	plan := &importPlan{}
+	groups := make(map[string]*importProject)
+	existingTitles := make(map[string]map[string]bool)
+
+	for _, entry := range entries {
+		group, ok := groups[entry.Project]
+		if !ok {
+			group = &importProject{name: entry.Project}
+			if entry.Project == "" {
+				// Inbox
+				group.project = &models.Project{}
+			} else if project, ok := byName[entry.Project]; ok {
+				group.project = project
+				data, err := c.GetProjectData(project.ID)
+				if err != nil {
+					return nil, err
+				}
+				titles := make(map[string]bool, len(data.Tasks))
+				for _, task := range data.Tasks {
+					titles[task.Title] = true
+				}
+				existingTitles[entry.Project] = titles
+			} else {
+				plan.newProjects++
+			}
+			groups[entry.Project] = group
+			plan.projects = append(plan.projects, group)
+		}
+
+		if existingTitles[entry.Project][entry.Task.Title] {
+			group.skipped = append(group.skipped, entry.Task)
+			continue
+		}
+		group.tasks = append(group.tasks, entry.Task)
+		plan.newTasks++
+	}
+
+	return plan, nil
+}
+
+func (p *importPlan) print() {
+	fmt.Println("导入预览：")
+	for _, group := range p.projects {
+		name := group.name
+		if name == "" {
+			name = "收集箱"
+		}
+		if group.project == nil {
+			fmt.Printf("+ 项目 %s（新建）\n", name)
+		} else {
+			fmt.Printf("  项目 %s\n", name)
+		}
+
+		for _, task := range group.tasks {
+			fmt.Printf("    + %s\n", describeImportTask(task))
+			for _, item := range task.Items {
+				fmt.Printf("        - %s\n", item.Title)
+			}
+		}
+		for _, task := range group.skipped {
+			fmt.Printf("    = %s（已存在，跳过）\n", task.Title)
+		}
+	}
+	fmt.Printf("\n共 %d 个新任务，%d 个新项目\n", p.newTasks, p.newProjects)
+}
+
+func describeImportTask(task models.Task) string {
+	parts := []string{task.Title}
+	if task.Priority != models.PriorityNone {
+		parts = append(parts, "["+task.Priority.String()+"]")
+	}
+	if task.DueDate != nil {
+		parts = append(parts, "截止 "+task.DueDate.String())
+	}
+	if len(task.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(task.Tags, " #"))
+	}
+	if task.Status == models.StatusCompleted {
+		parts = append(parts, "（创建后标记为完成）")
+	}
+	return strings.Join(parts, " ")
+}
+
+// apply creates missing projects and then all new tasks, completing the ones
+// that are done
+func (p *importPlan) apply(c *client.Client) error {
+	for _, group := range p.projects {
+		if len(group.tasks) == 0 {
+			continue
+		}
+		if group.project == nil {
+			created, err := c.CreateProject(&models.Project{Name: group.name})
+			if isDryRun(err) {
+				created = &models.Project{Name: group.name}
+			} else if err != nil {
+				return fmt.Errorf("创建项目 %s：%w", group.name, err)
+			}
+			group.project = created
+		}
+
+		for _, task := range group.tasks {
+			task.ProjectID = group.project.ID
+			created, err := c.CreateTask(&task)
+			if isDryRun(err) {
+				created = &models.Task{ProjectID: task.ProjectID}
+			} else if err != nil {
+				return fmt.Errorf("创建任务 %s：%w", task.Title, err)
+			}
+
+			// The API ignores the status of new tasks
+			if task.Status == models.StatusCompleted {
+				if err := c.CompleteTask(created.ProjectID, created.ID); err != nil && !isDryRun(err) {
+					return fmt.Errorf("完成任务 %s：%w", task.Title, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	tasksCmd.AddCommand(importTasksCmd)
+
+	importTasksCmd.Flags().StringP("format", "f", string(importer.FormatTodoTxt), "导入格式（todotxt, markdown, todoist-csv, taskwarrior）")
+	importTasksCmd.Flags().StringP("project", "p", "", "未指定项目的任务导入到此项目（默认为收集箱）")
+}
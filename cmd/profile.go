@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ticktick-tui/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "配置档案管理命令",
+	Long: `管理多个TickTick账号的配置档案。每个配置档案有独立的OAuth凭据、令牌和区域。
+
+使用 --profile 或环境变量 TICKTICK_PROFILE 临时切换配置档案，
+使用 profile use 修改默认配置档案。`,
+}
+
+var listProfilesCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有配置档案",
+	Long:  `列出所有配置档案，当前使用的配置档案以 * 标出。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles := core.Profiles()
+		if len(profiles) == 0 {
+			fmt.Println("没有找到配置档案")
+			return
+		}
+
+		for _, name := range profiles {
+			marker := " "
+			if name == core.ActiveProfile() {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, name)
+		}
+	},
+}
+
+var addProfileCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "添加配置档案",
+	Long:  `添加新的配置档案。使用 --from 可以复制另一个配置档案的OAuth凭据和区域（不包括令牌）。`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+
+		if dryRun {
+			if err := checkNewProfile(args[0], from); err != nil {
+				fmt.Printf("添加配置档案失败：%v\n", err)
+				os.Exit(1)
+			}
+			if from != "" {
+				fmt.Printf("[dry-run] 将添加配置档案 %s，并复制 %s 的OAuth凭据和区域\n", args[0], from)
+			} else {
+				fmt.Printf("[dry-run] 将添加配置档案 %s\n", args[0])
+			}
+			return
+		}
+
+		if err := core.AddProfile(args[0], from); err != nil {
+			fmt.Printf("添加配置档案失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("配置档案已添加：%s\n", args[0])
+		fmt.Printf("运行 'ticktick-tui --profile %s auth login' 登录该账号\n", args[0])
+	},
+}
+
+var useProfileCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "切换默认配置档案",
+	Long:  `设置未指定 --profile 时使用的配置档案。`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			if !core.ProfileExists(args[0]) {
+				fmt.Printf("切换配置档案失败：配置档案不存在：%s\n", args[0])
+				os.Exit(1)
+			}
+			fmt.Printf("[dry-run] 将把默认配置档案设为 %s\n", args[0])
+			return
+		}
+
+		if err := core.UseProfile(args[0]); err != nil {
+			fmt.Printf("切换配置档案失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("已切换到配置档案：%s\n", args[0])
+	},
+}
+
+var removeProfileCmd = &cobra.Command{
+	Use:     "rm <name>",
+	Aliases: []string{"remove"},
+	Short:   "删除配置档案",
+	Long:    `删除配置档案及其保存的凭据和令牌。`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmAction(fmt.Sprintf("确认删除配置档案 %s 及其凭据？", args[0])) {
+			fmt.Println("已取消")
+			return
+		}
+		if dryRun {
+			if !core.ProfileExists(args[0]) {
+				fmt.Printf("删除配置档案失败：配置档案不存在：%s\n", args[0])
+				os.Exit(1)
+			}
+			fmt.Printf("[dry-run] 将删除配置档案 %s 及其凭据\n", args[0])
+			return
+		}
+
+		if err := core.RemoveProfile(args[0]); err != nil {
+			fmt.Printf("删除配置档案失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("配置档案已删除：%s\n", args[0])
+	},
+}
+
+// checkNewProfile reports the error AddProfile would return for name and from
+func checkNewProfile(name, from string) error {
+	if err := core.ValidateProfileName(name); err != nil {
+		return err
+	}
+	if core.ProfileExists(name) {
+		return fmt.Errorf("配置档案已存在：%s", name)
+	}
+	if from != "" && !core.ProfileExists(from) {
+		return fmt.Errorf("配置档案不存在：%s", from)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(listProfilesCmd)
+	profileCmd.AddCommand(addProfileCmd)
+	profileCmd.AddCommand(useProfileCmd)
+	profileCmd.AddCommand(removeProfileCmd)
+
+	addProfileCmd.Flags().String("from", "", "复制此配置档案的OAuth凭据")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/models"
 
 	"github.com/spf13/cobra"
@@ -80,6 +81,9 @@ var createProjectCmd = &cobra.Command{
 		}
 
 		createdProject, err := client.CreateProject(project)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("创建项目失败：%v\n", err)
 			os.Exit(1)
@@ -114,7 +118,19 @@ var updateProjectCmd = &cobra.Command{
 			project.Kind = kind
 		}
 
+		prompt := fmt.Sprintf("确认更新项目 %s？", projectID)
+		if existing, err := client.GetProject(projectID); err == nil {
+			prompt = fmt.Sprintf("确认更新项目「%s」？", existing.Name)
+		}
+		if !confirmAction(prompt) {
+			fmt.Println("已取消")
+			return
+		}
+
 		updatedProject, err := client.UpdateProject(projectID, project)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("更新项目失败：%v\n", err)
 			os.Exit(1)
@@ -134,13 +150,26 @@ var deleteProjectCmd = &cobra.Command{
 		client := getClient()
 		projectID := args[0]
 
-		err := client.DeleteProject(projectID)
+		data, err := client.GetProjectData(projectID)
+		if err != nil {
+			fmt.Printf("获取项目数据失败：%v\n", err)
+			os.Exit(1)
+		}
+		if !confirmAction(fmt.Sprintf("确认删除项目「%s」及其 %d 个任务？", data.Project.Name, len(data.Tasks))) {
+			fmt.Println("已取消")
+			return
+		}
+
+		entry, err := core.TrashProject(client, data)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("删除项目失败：%v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("项目删除成功")
+		fmt.Printf("项目删除成功，可以通过 trash restore %s 恢复\n", entry.ID)
 	},
 }
 
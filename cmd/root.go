@@ -2,13 +2,24 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"ticktick-tui/internal/core"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var cfgFile string
+var (
+	cfgFile     string
+	profileName string
+	logFile     string
+	logLevel    string
+
+	// logCloser releases the current log file
+	logCloser io.Closer
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -35,25 +46,67 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ticktick-tui.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/ticktick-tui/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "使用的配置档案（也可以通过 TICKTICK_PROFILE 设置）")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "打印将要发送的请求而不实际执行")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "跳过所有确认提示")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "日志文件（TUI默认写入状态目录）")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "日志级别：debug、info、warn、error（默认info）")
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-	} else {
-		home, err := os.UserHomeDir()
+	if cfgFile == "" {
+		legacy, err := core.MigrateLegacyConfigFile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "无法迁移旧版配置文件：", err)
+		}
+
+		cfgFile, err = core.DefaultConfigFile()
 		cobra.CheckErr(err)
 
-		viper.AddConfigPath(home)
-		viper.SetConfigType("yaml")
-		viper.SetConfigName(".ticktick-tui")
+		if legacy != "" {
+			fmt.Fprintf(os.Stderr, "配置文件已从 %s 迁移到 %s\n", legacy, cfgFile)
+		}
 	}
+	viper.SetConfigFile(cfgFile)
+	viper.SetConfigType("yaml")
+	core.SetConfigFile(cfgFile)
 
-	viper.AutomaticEnv()
+	// Only TICKTICK_* variables, so unrelated ones like CLIENT_ID can't leak in
+	core.BindEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+
+		if err := core.MigrateLegacyConfig(); err != nil {
+			fmt.Fprintln(os.Stderr, "无法迁移旧版配置：", err)
+		}
+	}
+
+	if err := core.LoadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "警告：", err)
+	} else if err := core.ValidateConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告：配置文件存在问题，运行 'ticktick-tui config validate' 查看详情\n")
+	}
+
+	cobra.CheckErr(core.SelectProfile(profileName))
+	core.SetPassphrasePrompt(promptPassphrase)
+
+	setupLogging("")
+	slog.Info("config loaded", "file", viper.ConfigFileUsed(), "profile", core.ActiveProfile())
+}
+
+// setupLogging (re)starts logging; fallback is the log file used when
+// neither --log-file nor the config names one
+func setupLogging(fallback string) {
+	closer, err := core.SetupLogging(logFile, logLevel, fallback)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "无法初始化日志：", err)
+		return
+	}
+	if logCloser != nil {
+		logCloser.Close()
 	}
+	logCloser = closer
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"ticktick-tui/internal/core"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "凭据存储管理命令",
+	Long: `管理client_secret、access_token、refresh_token等凭据的存储位置。
+
+凭据按以下顺序查找：
+  1. 环境变量，例如 TICKTICK_ACCESS_TOKEN
+  2. 外部命令，例如 'config set access_token_cmd "pass show ticktick"'
+  3. secret_store 指定的存储：config（配置文件，明文）或 file（加密文件）
+
+使用加密文件时，密码来自 secrets_key_file 指定的密钥文件、
+环境变量 TICKTICK_SECRETS_PASSPHRASE，或在终端中输入。`,
+}
+
+var secretsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看凭据来源",
+	Long:  `显示当前配置档案的每个凭据来自哪里，不会显示凭据内容。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("存储方式：%s\n", core.SecretStore())
+		if core.SecretStore() == core.SecretStoreFile {
+			fmt.Printf("加密文件：%s\n", core.SecretsFile())
+			if err := core.UnlockSecrets(); err != nil {
+				fmt.Println("错误：", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Printf("\n配置档案 %s：\n", core.ActiveProfile())
+		for _, key := range []string{"client_secret", "access_token", "refresh_token"} {
+			source := core.SecretSource(key)
+			if source == "" {
+				source = "未设置"
+			}
+			fmt.Printf("  %-14s %s\n", key, source)
+		}
+	},
+}
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "将明文凭据迁移到加密文件",
+	Long: `把配置文件中以明文保存的所有配置档案的凭据移动到加密文件中。
+
+需要先运行 'config set secret_store file'。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if core.SecretStore() != core.SecretStoreFile {
+			fmt.Println("错误：请先运行 'ticktick-tui config set secret_store file'")
+			os.Exit(1)
+		}
+		if dryRun {
+			fmt.Printf("[dry-run] 将把明文凭据迁移到 %s\n", core.SecretsFile())
+			return
+		}
+
+		moved, err := core.MigrateSecrets()
+		if err != nil {
+			fmt.Printf("迁移凭据失败：%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已将 %d 个凭据迁移到 %s\n", moved, core.SecretsFile())
+	},
+}
+
+// promptPassphrase reads the passphrase of the encrypted secrets file from
+// the terminal, asking twice when a new file is created
+func promptPassphrase(create bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, core.ErrSecretsLocked
+	}
+
+	fmt.Fprint(os.Stderr, "凭据文件密码：")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if !create {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "确认密码：")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if string(again) != string(passphrase) {
+		return nil, errors.New("两次输入的密码不一致")
+	}
+	return passphrase, nil
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsStatusCmd)
+	secretsCmd.AddCommand(secretsMigrateCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"ticktick-tui/internal/core"
+	"ticktick-tui/internal/feed"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "本地服务命令",
+	Long:  `在本地运行为其他应用提供数据的服务。`,
+}
+
+var serveICSCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "运行本地iCalendar订阅服务",
+	Long: `启动本地HTTP服务，将有开始或截止日期的任务发布为iCalendar订阅。
+
+每个项目有单独的订阅，以项目ID命名（可通过 projects list 查看），项目改名后
+地址保持不变；另有一个包含所有项目的订阅 all.ics。订阅地址中包含随机令牌，
+令牌保存在配置项ics_token中，使用 --new-token 可以重新生成。
+
+服务在后台运行，刷新失败等信息写入日志文件（默认位于状态目录）。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// The server runs unattended, so it always logs somewhere
+		if file, err := core.DefaultLogFile(); err == nil {
+			setupLogging(file)
+		}
+
+		client := getClient()
+
+		addr, _ := cmd.Flags().GetString("addr")
+		interval, _ := cmd.Flags().GetDuration("refresh")
+		newToken, _ := cmd.Flags().GetBool("new-token")
+
+		if interval < time.Minute {
+			fmt.Println("错误：刷新间隔不能小于1分钟")
+			os.Exit(1)
+		}
+
+		token, err := core.GetSecret("ics_token")
+		if err != nil {
+			fmt.Printf("读取订阅令牌失败：%v\n", err)
+			os.Exit(1)
+		}
+		if token == "" || newToken {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				fmt.Printf("生成令牌失败：%v\n", err)
+				os.Exit(1)
+			}
+			token = hex.EncodeToString(buf)
+			if err := core.SaveConfig("ics_token", token); err != nil {
+				fmt.Printf("保存配置失败：%v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var cacheDir string
+		if dir, err := core.CacheDir(); err == nil {
+			cacheDir = filepath.Join(dir, "feeds")
+		}
+
+		server := feed.NewServer(client, token, interval, cacheDir)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := server.Refresh(); err != nil {
+			slog.Warn("feed refresh failed", "error", err)
+			fmt.Printf("刷新任务失败，将使用缓存数据：%v\n", err)
+		}
+
+		fmt.Println("订阅地址：")
+		for _, name := range server.Feeds() {
+			fmt.Printf("  http://%s/%s/%s.ics\n", addr, token, name)
+		}
+		fmt.Println("按 Ctrl+C 停止服务")
+
+		if err := server.Run(ctx, addr); err != nil {
+			fmt.Printf("服务运行失败：%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.AddCommand(serveICSCmd)
+
+	serveICSCmd.Flags().String("addr", "127.0.0.1:8765", "监听地址")
+	serveICSCmd.Flags().Duration("refresh", 15*time.Minute, "从API刷新数据的间隔")
+	serveICSCmd.Flags().Bool("new-token", false, "重新生成订阅令牌")
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"os"
 	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/models"
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var tasksCmd = &cobra.Command{
@@ -80,6 +80,9 @@ var createTaskCmd = &cobra.Command{
 		}
 
 		createdTask, err := client.CreateTask(task)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("创建任务失败：%v\n", err)
 			os.Exit(1)
@@ -129,6 +132,9 @@ var updateTaskCmd = &cobra.Command{
 		}
 
 		updatedTask, err := client.UpdateTask(taskID, task)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("更新任务失败：%v\n", err)
 			os.Exit(1)
@@ -150,6 +156,9 @@ var completeTaskCmd = &cobra.Command{
 		taskID := args[1]
 
 		err := client.CompleteTask(projectID, taskID)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("完成任务失败：%v\n", err)
 			os.Exit(1)
@@ -169,29 +178,50 @@ var deleteTaskCmd = &cobra.Command{
 		projectID := args[0]
 		taskID := args[1]
 
-		err := client.DeleteTask(projectID, taskID)
+		task, err := client.GetTask(projectID, taskID)
+		if err != nil {
+			fmt.Printf("获取任务失败：%v\n", err)
+			os.Exit(1)
+		}
+		if !confirmAction(fmt.Sprintf("确认删除任务「%s」？", task.Title)) {
+			fmt.Println("已取消")
+			return
+		}
+
+		entry, err := core.TrashTask(client, task)
+		if isDryRun(err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("删除任务失败：%v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("任务删除成功")
+		fmt.Printf("任务删除成功，可以通过 trash restore %s 恢复\n", entry.ID)
 	},
 }
 
 func getClient() *client.Client {
-	token := viper.GetString("access_token")
-	if token == "" {
-		token = viper.GetString("token")
+	if err := core.UnlockSecrets(); err != nil {
+		fmt.Println("错误：", err)
+		os.Exit(1)
 	}
 
-	if token == "" {
+	c := core.NewClient()
+	if c == nil {
 		fmt.Println("错误：未找到访问令牌")
 		fmt.Println("请先运行 'ticktick-tui auth login' 进行身份验证")
 		os.Exit(1)
 	}
 
-	return client.NewClient(token)
+	if info := core.GetTokenInfo(); info.Expired() && !info.HasRefreshToken {
+		fmt.Fprintln(os.Stderr, "警告：访问令牌已过期，请运行 'ticktick-tui auth login' 重新登录")
+	}
+
+	if dryRun {
+		c.SetDryRun(os.Stdout)
+	}
+	return c
 }
 
 func printTaskJSON(task *models.Task) {
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"ticktick-tui/internal/core"
+	"ticktick-tui/internal/trash"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "回收站命令",
+	Long: `管理本地回收站。删除任务或项目前会先在本地保存完整快照，
+可以在保留期（配置项trash_retention_days，默认30天）内恢复。`,
+}
+
+var listTrashCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出回收站中的条目",
+	Long:  `列出回收站中的所有任务和项目，最近删除的排在最前。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := core.OpenTrash()
+		if err != nil {
+			fmt.Printf("打开回收站失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		entries, err := store.List()
+		if err != nil {
+			fmt.Printf("读取回收站失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("回收站为空")
+			return
+		}
+
+		for _, entry := range entries {
+			kind := "任务"
+			title := entry.Title()
+			if entry.Kind == trash.KindProject {
+				kind = "项目"
+				title = fmt.Sprintf("%s（%d 个任务）", title, len(entry.Project.Tasks))
+			}
+
+			expires := ""
+			if at, ok := store.ExpiresAt(&entry); ok {
+				expires = fmt.Sprintf("  %s 过期", at.Format("2006-01-02"))
+			}
+
+			fmt.Printf("%s  %s  %s  %s%s\n",
+				entry.ID, entry.DeletedAt.Format("2006-01-02 15:04"), kind, title, expires)
+		}
+	},
+}
+
+var restoreTrashCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "恢复回收站中的条目",
+	Long:  `通过API重新创建回收站中的任务或项目（包括项目中的任务）。恢复后的对象会有新的ID。`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+
+		entry, err := core.RestoreFromTrash(client, args[0])
+		if isDryRun(err) {
+			return
+		}
+		if errors.Is(err, trash.ErrNotFound) {
+			fmt.Printf("回收站中没有找到：%s\n", args[0])
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("恢复失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("已恢复：%s\n", entry.Title())
+	},
+}
+
+var purgeTrashCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "清空回收站",
+	Long:  `永久删除回收站中的所有条目。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := core.OpenTrash()
+		if err != nil {
+			fmt.Printf("打开回收站失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		if !confirmAction("确认清空回收站？清空后将无法恢复") {
+			fmt.Println("已取消")
+			return
+		}
+		if dryRun {
+			entries, err := store.List()
+			if err != nil {
+				fmt.Printf("读取回收站失败：%v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("[dry-run] 将清空回收站中的 %d 个条目\n", len(entries))
+			return
+		}
+
+		removed, err := store.Purge(time.Now())
+		if err != nil {
+			fmt.Printf("清空回收站失败：%v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("已清空 %d 个条目\n", removed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(listTrashCmd)
+	trashCmd.AddCommand(restoreTrashCmd)
+	trashCmd.AddCommand(purgeTrashCmd)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,6 +15,17 @@ var tuiCmd = &cobra.Command{
 	Short: "启动TUI界面",
 	Long:  `启动交互式终端用户界面来管理TickTick任务和项目。`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// The TUI can't print errors, so it always logs somewhere
+		if file, err := core.DefaultLogFile(); err == nil {
+			setupLogging(file)
+		}
+
+		// Ask for the passphrase before the alternate screen takes over
+		if err := core.UnlockSecrets(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
 		model := tui.NewModel()
 
 		p := tea.NewProgram(model, tea.WithAltScreen())
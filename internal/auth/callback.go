@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// ErrNotLoopback is returned when the redirect URI does not point at this machine
+var ErrNotLoopback = errors.New("redirect URI is not a loopback address")
+
+const callbackPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>ticktick-tui</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 4em">
+<h2>%s</h2><p>%s</p>
+</body></html>`
+
+type callbackResult struct {
+	code  string
+	state string
+	err   error
+}
+
+// CallbackServer is a temporary HTTP listener on the redirect URI that
+// captures the authorization code of a single login
+type CallbackServer struct {
+	server  *http.Server
+	path    string
+	session *Session
+	result  chan callbackResult
+}
+
+// ListenForCallback binds the host and port of redirectURI. Only http
+// redirect URIs on localhost or a loopback IP can be served. Callbacks whose
+// state does not match session are rejected.
+func ListenForCallback(redirectURI string, session *Session) (*CallbackServer, error) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return nil, fmt.Errorf("parsing redirect URI: %w", err)
+	}
+	if u.Scheme != "http" || !isLoopback(u.Hostname()) {
+		return nil, ErrNotLoopback
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("listening on redirect URI: %w", err)
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	s := &CallbackServer{path: path, session: session, result: make(chan callbackResult, 1)}
+	s.server = &http.Server{Handler: http.HandlerFunc(s.handle), ReadHeaderTimeout: 10 * time.Second}
+
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+func (s *CallbackServer) handle(w http.ResponseWriter, r *http.Request) {
+	// Browsers also ask for things like /favicon.ico
+	if r.URL.Path != s.path {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var result callbackResult
+	switch {
+	case s.session.Verify(query.Get("state")) != nil:
+		// Not our login; keep waiting for the real callback
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, callbackPage, "授权失败", html.EscapeString(ErrStateMismatch.Error()))
+		return
+	case query.Get("error") != "":
+		result.err = fmt.Errorf("authorization denied: %s", query.Get("error"))
+	case query.Get("code") == "":
+		result.err = errors.New("callback is missing the authorization code")
+	default:
+		result.code = query.Get("code")
+		result.state = query.Get("state")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if result.err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, callbackPage, "授权失败", html.EscapeString(result.err.Error()))
+	} else {
+		fmt.Fprintf(w, callbackPage, "授权成功", "现在可以关闭此页面并返回终端。")
+	}
+
+	// Only the first callback counts
+	select {
+	case s.result <- result:
+	default:
+	}
+}
+
+// Wait blocks until the callback arrives or ctx is done, then shuts the
+// listener down. It returns the authorization code and the verified state.
+func (s *CallbackServer) Wait(ctx context.Context) (code, state string, err error) {
+	defer s.Close()
+
+	select {
+	case result := <-s.result:
+		return result.code, result.state, result.err
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	}
+}
+
+// Close stops the listener
+func (s *CallbackServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// OpenBrowser opens url in the default browser
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
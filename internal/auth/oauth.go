@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"ticktick-tui/internal/logging"
 	"ticktick-tui/internal/models"
 )
 
@@ -20,29 +21,69 @@ type OAuthClient struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURI  string
+
+	// Override AuthURL and TokenURL, e.g. for Dida365
+	AuthEndpoint  string
+	TokenEndpoint string
+}
+
+func (c *OAuthClient) authEndpoint() string {
+	if c.AuthEndpoint != "" {
+		return c.AuthEndpoint
+	}
+	return AuthURL
 }
 
-// GetAuthURL generates the authorization URL
-func (c *OAuthClient) GetAuthURL() string {
+func (c *OAuthClient) tokenEndpoint() string {
+	if c.TokenEndpoint != "" {
+		return c.TokenEndpoint
+	}
+	return TokenURL
+}
+
+// GetAuthURL generates the authorization URL for a login session
+func (c *OAuthClient) GetAuthURL(session *Session) string {
 	params := url.Values{}
 	params.Set("client_id", c.ClientID)
 	params.Set("scope", "tasks:read tasks:write")
-	params.Set("state", "ticktick-tui-state")
+	params.Set("state", session.State)
 	params.Set("redirect_uri", c.RedirectURI)
 	params.Set("response_type", "code")
+	if session.CodeVerifier != "" {
+		params.Set("code_challenge", session.CodeChallenge())
+		params.Set("code_challenge_method", "S256")
+	}
 
-	return AuthURL + "?" + params.Encode()
+	return c.authEndpoint() + "?" + params.Encode()
 }
 
-// ExchangeCodeForToken exchanges authorization code for access token
-func (c *OAuthClient) ExchangeCodeForToken(code, scope string) (*models.OAuthToken, error) {
+// ExchangeCodeForToken exchanges authorization code for access token.
+// codeVerifier is sent when the login used PKCE.
+func (c *OAuthClient) ExchangeCodeForToken(code, scope, codeVerifier string) (*models.OAuthToken, error) {
 	data := url.Values{}
 	data.Set("code", code)
 	data.Set("grant_type", "authorization_code")
 	data.Set("scope", scope)
 	data.Set("redirect_uri", c.RedirectURI)
+	if codeVerifier != "" {
+		data.Set("code_verifier", codeVerifier)
+	}
+
+	return c.requestToken(data)
+}
+
+// RefreshToken obtains a new access token using a refresh token
+func (c *OAuthClient) RefreshToken(refreshToken string) (*models.OAuthToken, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
+
+	return c.requestToken(data)
+}
 
-	req, err := http.NewRequest("POST", TokenURL, strings.NewReader(data.Encode()))
+// requestToken posts a token request authenticated with the client credentials
+func (c *OAuthClient) requestToken(data url.Values) (*models.OAuthToken, error) {
+	req, err := http.NewRequest("POST", c.tokenEndpoint(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -52,7 +93,7 @@ func (c *OAuthClient) ExchangeCodeForToken(code, scope string) (*models.OAuthTok
 	req.Header.Set("Authorization", "Basic "+auth)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
+	client := &http.Client{Transport: &logging.Transport{}}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %w", err)
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// ErrStateMismatch is returned when the state of a callback does not belong
+// to the pending login
+var ErrStateMismatch = errors.New("OAuth state mismatch")
+
+// Session holds the secrets of one login attempt that have to survive
+// between generating the authorization URL and exchanging the code
+type Session struct {
+	State        string    `json:"state"`
+	CodeVerifier string    `json:"codeVerifier,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// NewSession creates a session with a random state and, if pkce is set, a
+// random PKCE code verifier
+func NewSession(pkce bool) (*Session, error) {
+	state, err := randomString(24)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{State: state, CreatedAt: time.Now()}
+
+	if pkce {
+		// 32 random bytes give the recommended 43 character verifier
+		verifier, err := randomString(32)
+		if err != nil {
+			return nil, err
+		}
+		session.CodeVerifier = verifier
+	}
+
+	return session, nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge for the code verifier
+func (s *Session) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Verify checks the state returned by the authorization server
+func (s *Session) Verify(state string) error {
+	if subtle.ConstantTimeCompare([]byte(state), []byte(s.State)) != 1 {
+		return ErrStateMismatch
+	}
+	return nil
+}
+
+// Expired reports whether the session is older than maxAge
+func (s *Session) Expired(maxAge time.Duration) bool {
+	return time.Since(s.CreatedAt) > maxAge
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -3,51 +3,163 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"sync"
+	"ticktick-tui/internal/logging"
 	"ticktick-tui/internal/models"
+	"time"
 )
 
 const BaseURL = "https://api.ticktick.com"
 
+// ErrDryRun is returned instead of sending a mutating request in dry-run mode
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// APIError is returned when the API answers with an unexpected status code
+type APIError struct {
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d", e.StatusCode)
+}
+
+// IsUnauthorized reports whether err means that the access token was rejected
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// TokenRefresher obtains a new access token and its expiry time
+type TokenRefresher func() (accessToken string, expiresAt time.Time, err error)
+
 type Client struct {
 	accessToken string
+	baseURL     string
 	httpClient  *http.Client
+	dryRun      io.Writer
+
+	mu        sync.Mutex
+	refresh   TokenRefresher
+	expiresAt time.Time
 }
 
 // NewClient creates a new TickTick API client
 func NewClient(accessToken string) *Client {
 	return &Client{
 		accessToken: accessToken,
-		httpClient:  &http.Client{},
+		baseURL:     BaseURL,
+		httpClient:  &http.Client{Transport: &logging.Transport{}},
 	}
 }
 
+// SetBaseURL points the client at another API host, e.g. Dida365
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+}
+
+// SetTokenRefresher lets the client renew its access token shortly before
+// expiresAt and whenever the API rejects it. A zero expiresAt means the
+// expiry is unknown, so the token is only renewed after a 401.
+func (c *Client) SetTokenRefresher(refresh TokenRefresher, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh = refresh
+	c.expiresAt = expiresAt
+}
+
+// SetDryRun makes the client print mutating requests to w instead of sending
+// them. Read-only requests are still performed. Pass nil to disable.
+func (c *Client) SetDryRun(w io.Writer) {
+	c.dryRun = w
+}
+
 // makeRequest performs HTTP request with proper headers
 func (c *Client) makeRequest(method, endpoint string, body interface{}) (*http.Response, error) {
-	var reqBody io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshaling request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, BaseURL+endpoint, reqBody)
+	if c.dryRun != nil && method != "GET" {
+		fmt.Fprintf(c.dryRun, "[dry-run] %s %s\n", method, c.baseURL+endpoint)
+		if body != nil {
+			if pretty, err := json.MarshalIndent(body, "", "  "); err == nil {
+				fmt.Fprintln(c.dryRun, string(pretty))
+			}
+		}
+		return nil, ErrDryRun
+	}
+
+	// Renew the token a little before it runs out
+	c.mu.Lock()
+	if c.refresh != nil && !c.expiresAt.IsZero() && time.Until(c.expiresAt) < time.Minute {
+		c.refreshToken()
+	}
+	c.mu.Unlock()
+
+	resp, err := c.do(method, endpoint, jsonBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.mu.Lock()
+		refreshed := c.refresh != nil && c.refreshToken() == nil
+		c.mu.Unlock()
+		if refreshed {
+			resp.Body.Close()
+			return c.do(method, endpoint, jsonBody)
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) do(method, endpoint string, jsonBody []byte) (*http.Response, error) {
+	var reqBody io.Reader
+	if jsonBody != nil {
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
+	c.mu.Lock()
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	if body != nil {
+	c.mu.Unlock()
+	if jsonBody != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
 	return c.httpClient.Do(req)
 }
 
+// refreshToken replaces the access token; c.mu must be held. After a failed
+// refresh the client stops trying and keeps using the old token.
+func (c *Client) refreshToken() error {
+	token, expiresAt, err := c.refresh()
+	if err != nil {
+		slog.Warn("refreshing access token failed", "error", err)
+		c.refresh = nil
+		return err
+	}
+	slog.Info("access token refreshed", "expires_at", expiresAt)
+	c.accessToken = token
+	c.expiresAt = expiresAt
+	return nil
+}
+
 // GetTask retrieves a task by project ID and task ID
 func (c *Client) GetTask(projectID, taskID string) (*models.Task, error) {
 	endpoint := fmt.Sprintf("/open/v1/project/%s/task/%s", projectID, taskID)
@@ -58,7 +170,7 @@ func (c *Client) GetTask(projectID, taskID string) (*models.Task, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var task models.Task
@@ -78,7 +190,7 @@ func (c *Client) CreateTask(task *models.Task) (*models.Task, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var createdTask models.Task
@@ -99,7 +211,7 @@ func (c *Client) UpdateTask(taskID string, task *models.Task) (*models.Task, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var updatedTask models.Task
@@ -120,7 +232,29 @@ func (c *Client) CompleteTask(projectID, taskID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("API error: %d", resp.StatusCode)
+		return &APIError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// ReopenTask marks a completed task as not completed
+func (c *Client) ReopenTask(projectID, taskID string) error {
+	endpoint := fmt.Sprintf("/open/v1/task/%s", taskID)
+	// Task omits a zero status, so the body is spelled out
+	body := map[string]any{
+		"id":        taskID,
+		"projectId": projectID,
+		"status":    models.StatusNormal,
+	}
+	resp, err := c.makeRequest("POST", endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &APIError{StatusCode: resp.StatusCode}
 	}
 
 	return nil
@@ -136,7 +270,7 @@ func (c *Client) DeleteTask(projectID, taskID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("API error: %d", resp.StatusCode)
+		return &APIError{StatusCode: resp.StatusCode}
 	}
 
 	return nil
@@ -151,7 +285,7 @@ func (c *Client) GetProjects() ([]models.Project, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var projects []models.Project
@@ -172,7 +306,7 @@ func (c *Client) GetProject(projectID string) (*models.Project, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var project models.Project
@@ -193,7 +327,7 @@ func (c *Client) GetProjectData(projectID string) (*models.ProjectData, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var projectData models.ProjectData
@@ -213,7 +347,7 @@ func (c *Client) CreateProject(project *models.Project) (*models.Project, error)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var createdProject models.Project
@@ -234,7 +368,7 @@ func (c *Client) UpdateProject(projectID string, project *models.Project) (*mode
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var updatedProject models.Project
@@ -255,7 +389,7 @@ func (c *Client) DeleteProject(projectID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("API error: %d", resp.StatusCode)
+		return &APIError{StatusCode: resp.StatusCode}
 	}
 
 	return nil
@@ -0,0 +1,442 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the typed form of the configuration file. Secrets are not part
+// of it, they are read through GetSecret.
+type Config struct {
+	CurrentProfile     string                   `mapstructure:"current_profile"`
+	SecretStore        string                   `mapstructure:"secret_store"`
+	SecretsFile        string                   `mapstructure:"secrets_file"`
+	SecretsKeyFile     string                   `mapstructure:"secrets_key_file"`
+	TrashRetentionDays *int                     `mapstructure:"trash_retention_days"`
+	TUI                TUIConfig                `mapstructure:"tui"`
+	Log                LogConfig                `mapstructure:"log"`
+	Profiles           map[string]ProfileConfig `mapstructure:"profiles"`
+}
+
+// LogConfig holds the logging settings; --log-file and --log-level take
+// precedence
+type LogConfig struct {
+	File  string `mapstructure:"file"`
+	Level string `mapstructure:"level"`
+	// MaxSize in megabytes before the log file is rotated
+	MaxSize    *int `mapstructure:"max_size"`
+	MaxBackups *int `mapstructure:"max_backups"`
+}
+
+// TUIConfig holds the settings of the terminal UI. They are applied while
+// the TUI is running when the file changes.
+type TUIConfig struct {
+	// RefreshInterval reloads the open list periodically, 0 disables it
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	// Colors replaces palette colors, e.g. blue: "#5f87ff"
+	Colors map[string]string `mapstructure:"colors"`
+	// Keymap selects the key bindings: default, vim or emacs
+	Keymap string `mapstructure:"keymap"`
+	// Keys replaces the keys of actions, e.g. delete: [x, "d d"]
+	Keys map[string][]string `mapstructure:"keys"`
+}
+
+// ProfileConfig holds the settings of one profile
+type ProfileConfig struct {
+	ClientID        string `mapstructure:"client_id"`
+	RedirectURI     string `mapstructure:"redirect_uri"`
+	Region          string `mapstructure:"region"`
+	OAuthPKCE       *bool  `mapstructure:"oauth_pkce"`
+	TokenExpiresAt  string `mapstructure:"token_expires_at"`
+	TokenScope      string `mapstructure:"token_scope"`
+	ClientSecretCmd string `mapstructure:"client_secret_cmd"`
+	AccessTokenCmd  string `mapstructure:"access_token_cmd"`
+	RefreshTokenCmd string `mapstructure:"refresh_token_cmd"`
+}
+
+// secretCommand returns the command configured to print a secret
+func (p ProfileConfig) secretCommand(key string) string {
+	switch key {
+	case "client_secret":
+		return p.ClientSecretCmd
+	case "access_token":
+		return p.AccessTokenCmd
+	case "refresh_token":
+		return p.RefreshTokenCmd
+	}
+	return ""
+}
+
+// PKCE reports whether logins use PKCE, which is the default
+func (p ProfileConfig) PKCE() bool {
+	return p.OAuthPKCE == nil || *p.OAuthPKCE
+}
+
+// TrashRetention returns the number of days trashed items are kept
+func (c *Config) TrashRetention() int {
+	if c.TrashRetentionDays == nil {
+		return DefaultTrashRetentionDays
+	}
+	return *c.TrashRetentionDays
+}
+
+// Profile returns the settings of a profile
+func (c *Config) Profile(name string) ProfileConfig {
+	return c.Profiles[name]
+}
+
+// EnvPrefix is prepended to upper-cased setting names to form the
+// environment variables that override them, e.g. TICKTICK_REDIRECT_URI
+const EnvPrefix = "TICKTICK"
+
+// BindEnv lets TICKTICK_* environment variables override settings. Per-profile
+// settings set this way apply to whichever profile is active.
+func BindEnv() {
+	viper.SetEnvPrefix(EnvPrefix)
+	for _, key := range append(sharedKeys(), profileKeys...) {
+		if key != "profiles" && !slices.Contains(secretKeys, key) {
+			viper.BindEnv(key)
+		}
+	}
+}
+
+// envVar returns the environment variable that overrides a setting
+func envVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(key)
+}
+
+var (
+	// configMu guards config and the global viper instance, which the TUI
+	// reads from background goroutines
+	configMu sync.RWMutex
+	// config is loaded once at startup and again whenever the file is written
+	config = &Config{}
+)
+
+// LoadConfig decodes the configuration read by viper. The previous
+// configuration is kept if it cannot be decoded.
+func LoadConfig() error {
+	configMu.Lock()
+	defer configMu.Unlock()
+	return loadConfig()
+}
+
+func loadConfig() error {
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return fmt.Errorf("无法解析配置文件：%w", err)
+	}
+	config = &cfg
+	return nil
+}
+
+// readInConfig reads the configuration file at path into viper and decodes it
+func readInConfig(path string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	return loadConfig()
+}
+
+// CurrentConfig returns the loaded configuration. It is replaced, not
+// changed, when the file is reloaded.
+func CurrentConfig() *Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// CurrentProfile returns the settings of the active profile, with
+// environment variable overrides applied
+func CurrentProfile() ProfileConfig {
+	profile := CurrentConfig().Profile(activeProfile)
+
+	overrides := map[string]any{}
+	for _, key := range structKeys(reflect.TypeOf(ProfileConfig{})) {
+		if value, ok := os.LookupEnv(envVar(key)); ok {
+			overrides[key] = value
+		}
+	}
+	if len(overrides) > 0 {
+		v := viper.New()
+		if err := v.MergeConfigMap(overrides); err == nil {
+			v.Unmarshal(&profile)
+		}
+	}
+	return profile
+}
+
+// configFile is the file that configuration changes are written to
+var configFile string
+
+// SetConfigFile sets the file that SaveConfig and UnsetConfig write to
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// ConfigFile returns the path of the configuration file
+func ConfigFile() string {
+	if configFile != "" {
+		return configFile
+	}
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return viper.ConfigFileUsed()
+}
+
+// SaveConfig validates and stores a setting. Per-profile settings go to the
+// active profile.
+func SaveConfig(key, value string) error {
+	if slices.Contains(secretKeys, key) {
+		return setSecret(activeProfile, key, value)
+	}
+
+	typed, err := parseSetting(key, value)
+	if err != nil {
+		return err
+	}
+	if err := validateSetting(key, value); err != nil {
+		return err
+	}
+
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	setNested(settings, strings.Split(configKey(key), "."), typed)
+	return writeConfigFile(settings)
+}
+
+// CheckSetting reports the error SaveConfig would return for key and value
+// without saving anything
+func CheckSetting(key, value string) error {
+	if slices.Contains(secretKeys, key) {
+		return nil
+	}
+	if _, err := parseSetting(key, value); err != nil {
+		return err
+	}
+	return validateSetting(key, value)
+}
+
+// UnsetConfig removes a key from the configuration file
+func UnsetConfig(key string) error {
+	if !IsKnownKey(key) {
+		return &ValidationError{Key: key, Message: "未知的配置项"}
+	}
+	if slices.Contains(secretKeys, key) {
+		return deleteSecret(activeProfile, key)
+	}
+
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	deleteNested(settings, strings.Split(configKey(key), "."))
+	return writeConfigFile(settings)
+}
+
+// InstallConfigFile validates the file at src and, if it is valid, replaces
+// the configuration file with it
+func InstallConfigFile(src string) error {
+	if err := ValidateConfigFile(src); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	path := ConfigFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	err = ownWrite(func() error {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return err
+		}
+		return os.Chmod(path, 0o600)
+	})
+	if err != nil {
+		return err
+	}
+	return readInConfig(path)
+}
+
+// readConfigFile returns the settings stored in the configuration file only,
+// without environment variables or defaults mixed in
+func readConfigFile() (map[string]any, error) {
+	path := ConfigFile()
+	if path == "" {
+		return nil, errors.New("未设置配置文件路径")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configType(path))
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]any{}, nil
+		}
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// writeConfigFile replaces the configuration file and reloads it
+func writeConfigFile(settings map[string]any) error {
+	path := ConfigFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigType(configType(path))
+	for key, value := range settings {
+		v.Set(key, value)
+	}
+	err := ownWrite(func() error {
+		if err := v.WriteConfigAs(path); err != nil {
+			return err
+		}
+		// The file holds credentials
+		return os.Chmod(path, 0o600)
+	})
+	if err != nil {
+		return err
+	}
+	return readInConfig(path)
+}
+
+func configType(path string) string {
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		return ext
+	}
+	return "yaml"
+}
+
+func setNested(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func deleteNested(m map[string]any, path []string) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			return
+		}
+		m = next
+	}
+	delete(m, path[len(path)-1])
+}
+
+// sharedKeys returns the settings that are not stored per profile. Settings
+// in nested sections are named with dots, e.g. tui.refresh_interval.
+func sharedKeys() []string {
+	return structKeys(reflect.TypeOf(Config{}))
+}
+
+// structKeys lists the mapstructure names of a config struct's fields,
+// descending into nested structs
+func structKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("mapstructure")
+		if t.Field(i).Type.Kind() == reflect.Struct && t.Field(i).Type != reflect.TypeOf(time.Time{}) {
+			for _, key := range structKeys(t.Field(i).Type) {
+				keys = append(keys, name+"."+key)
+			}
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// settingType returns the type of a setting's field, or string for settings
+// like secrets that are not part of Config
+func settingType(key string) reflect.Type {
+	t := reflect.TypeOf(Config{})
+	if isProfileKey(key) {
+		t = reflect.TypeOf(ProfileConfig{})
+	}
+
+	path := strings.Split(key, ".")
+	for _, name := range path {
+		if t.Kind() != reflect.Struct {
+			// Entry of a map such as tui.colors
+			return t.Elem()
+		}
+		field, ok := fieldByTag(t, name)
+		if !ok {
+			return reflect.TypeOf("")
+		}
+		t = field.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return t
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// parseSetting converts a value given on the command line to the type of
+// the setting
+func parseSetting(key, value string) (any, error) {
+	t := settingType(key)
+	if t == reflect.TypeOf(time.Duration(0)) {
+		// Stored as written, e.g. 5m
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, &ValidationError{Key: key, Message: "必须是时长，例如 30s、5m"}
+		}
+		return value, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, &ValidationError{Key: key, Message: "必须是整数"}
+		}
+		return n, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, &ValidationError{Key: key, Message: "必须是 true 或 false"}
+		}
+		return b, nil
+	case reflect.Map, reflect.Struct, reflect.Slice:
+		return nil, &ValidationError{Key: key, Message: "不能直接设置，请使用 config edit"}
+	}
+	return value, nil
+}
@@ -3,83 +3,71 @@ package core
 import (
 	"fmt"
 	"ticktick-tui/internal/auth"
-	"ticktick-tui/internal/client"
 	"ticktick-tui/internal/models"
-
-	"github.com/spf13/viper"
 )
 
-func SaveConfig(key, value string) error {
-
-	viper.Set(key, nil)
-	viper.Set(key, value)
-
-	if err := viper.WriteConfig(); err != nil {
-		if err := viper.SafeWriteConfig(); err != nil {
-			return err
-		}
+// GetAuthURL starts a new login with a random state and returns its
+// authorization URL
+func GetAuthURL() (string, error) {
+	session, err := newAuthSession()
+	if err != nil {
+		return "", fmt.Errorf("创建登录会话失败：%w", err)
 	}
 
-	return nil
+	return oauthClient().GetAuthURL(session), nil
 }
 
-func GetAuthURL() string {
-	client := &auth.OAuthClient{
-		ClientID:    viper.GetString("client_id"),
-		RedirectURI: viper.GetString("redirect_uri"),
+// ListenForCallback starts a temporary listener on the configured
+// redirect_uri that captures the authorization code of the pending login
+func ListenForCallback() (*auth.CallbackServer, error) {
+	session, err := loadAuthSession()
+	if err != nil {
+		return nil, err
 	}
-
-	return client.GetAuthURL()
+	return auth.ListenForCallback(CurrentProfile().RedirectURI, session)
 }
 
-func GetToken(code string) (*models.OAuthToken, error) {
-	client := &auth.OAuthClient{
-		ClientID:     viper.GetString("client_id"),
-		ClientSecret: viper.GetString("client_secret"),
-		RedirectURI:  viper.GetString("redirect_uri"),
-	}
+func GetToken(code, codeVerifier string) (*models.OAuthToken, error) {
 	scope := "tasks:read tasks:write"
 
-	return client.ExchangeCodeForToken(code, scope)
+	return oauthClient().ExchangeCodeForToken(code, scope, codeVerifier)
 }
 
-func getClient() *client.Client {
-	token := viper.GetString("access_token")
-	if token == "" {
-		token = viper.GetString("token")
+// Login exchanges the authorization code of the pending login for a token
+// and saves it. The state is verified against the pending login.
+func Login(code, state string) (*models.OAuthToken, error) {
+	token, err := ExchangeAuthCode(code, state)
+	if err != nil {
+		return nil, err
 	}
-
-	if token == "" {
-		return nil
+	if err := FinishLogin(token); err != nil {
+		return nil, err
 	}
-
-	return client.NewClient(token)
+	return token, nil
 }
 
-func GetProjects() ([]models.Project, error) {
-	client := getClient()
-	if client == nil {
-		return nil, fmt.Errorf("未找到访问令牌")
+// ExchangeAuthCode exchanges the authorization code of the pending login for
+// a token without saving it. Codes without a state are rejected, the state
+// guards against codes of logins that were not started here.
+func ExchangeAuthCode(code, state string) (*models.OAuthToken, error) {
+	if state == "" {
+		return nil, ErrMissingState
 	}
-
-	projects, err := client.GetProjects()
+	session, err := loadAuthSession()
 	if err != nil {
-		return nil, fmt.Errorf("获取项目列表失败：%v", err)
+		return nil, err
 	}
-
-	return projects, nil
-}
-
-func GetTasks(projectID string) ([]models.Task, error) {
-	client := getClient()
-	if client == nil {
-		return nil, fmt.Errorf("未找到访问令牌")
+	if err := session.Verify(state); err != nil {
+		return nil, err
 	}
+	return GetToken(code, session.CodeVerifier)
+}
 
-	tasks, err := client.GetProjectData(projectID)
-	if err != nil {
-		return nil, fmt.Errorf("获取任务列表失败：%v", err)
+// FinishLogin saves the token of the pending login and ends it
+func FinishLogin(token *models.OAuthToken) error {
+	if err := SaveToken(token); err != nil {
+		return err
 	}
-
-	return tasks.Tasks, nil
+	clearAuthSession()
+	return nil
 }
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"io"
+	"path/filepath"
+	"ticktick-tui/internal/logging"
+)
+
+const (
+	DefaultLogMaxSize    = 10 // megabytes
+	DefaultLogMaxBackups = 3
+)
+
+// DefaultLogFile is where the TUI logs when no log file is configured
+func DefaultLogFile() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName+".log"), nil
+}
+
+// SetupLogging installs the default logger. file and level override the
+// log section of the config; fallback is used when neither names a file.
+// Without a file nothing is logged.
+func SetupLogging(file, level, fallback string) (io.Closer, error) {
+	cfg := CurrentConfig().Log
+	if file == "" {
+		file = cfg.File
+	}
+	if file == "" {
+		file = fallback
+	}
+	if level == "" {
+		level = cfg.Level
+	}
+
+	maxSize := DefaultLogMaxSize
+	if cfg.MaxSize != nil {
+		maxSize = *cfg.MaxSize
+	}
+	maxBackups := DefaultLogMaxBackups
+	if cfg.MaxBackups != nil {
+		maxBackups = *cfg.MaxBackups
+	}
+
+	return logging.Setup(logging.Options{
+		File:       file,
+		Level:      level,
+		MaxSize:    int64(maxSize) << 20,
+		MaxBackups: maxBackups,
+	})
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const appName = "ticktick-tui"
+
+// ConfigDir returns the directory of the configuration file. It follows
+// $XDG_CONFIG_HOME, which is also honored on macOS and Windows when set.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", appName), nil
+}
+
+// DefaultConfigFile returns where the configuration file lives when --config
+// is not given
+func DefaultConfigFile() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// legacyConfigFile is where the configuration file used to live
+func legacyConfigFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "."+appName+".yaml"), nil
+}
+
+// MigrateLegacyConfigFile moves ~/.ticktick-tui.yaml, and the encrypted
+// secrets file next to it, to the default location. Nothing happens if the
+// new file already exists. It returns the path of the legacy file if it was
+// moved.
+func MigrateLegacyConfigFile() (string, error) {
+	legacy, err := legacyConfigFile()
+	if err != nil {
+		return "", err
+	}
+	path, err := DefaultConfigFile()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := moveFile(legacy, path); err != nil {
+		return "", err
+	}
+
+	legacySecrets := strings.TrimSuffix(legacy, ".yaml") + ".secrets"
+	if _, err := os.Stat(legacySecrets); err == nil {
+		if err := moveFile(legacySecrets, strings.TrimSuffix(path, ".yaml")+".secrets"); err != nil {
+			return legacy, err
+		}
+	}
+	return legacy, nil
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// CacheDir returns the active profile's directory for disposable data,
+// creating it if needed. It follows $XDG_CACHE_HOME where set.
+func CacheDir() (string, error) {
+	return cacheDir(activeProfile)
+}
+
+func cacheDir(profile string) (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		base = dir
+	}
+	dir := profileDir(filepath.Join(base, appName), profile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// StateDir returns the active profile's directory for persistent state such
+// as the trash, creating it if needed. It follows $XDG_STATE_HOME where set.
+func StateDir() (string, error) {
+	return stateDir(activeProfile)
+}
+
+func stateDir(profile string) (string, error) {
+	var base string
+	switch {
+	case os.Getenv("XDG_STATE_HOME") != "":
+		base = os.Getenv("XDG_STATE_HOME")
+	case runtime.GOOS == "windows" || runtime.GOOS == "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		base = dir
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+
+	dir := profileDir(filepath.Join(base, appName), profile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	DefaultProfile = "default"
+
+	// ProfileEnv selects the profile when --profile is not given
+	ProfileEnv = "TICKTICK_PROFILE"
+)
+
+// profileKeys are stored per profile under profiles.<name>; everything else
+// is shared by all profiles
+var profileKeys = append(structKeys(reflect.TypeOf(ProfileConfig{})), secretKeys...)
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var activeProfile = DefaultProfile
+
+// SelectProfile picks the active profile from the --profile flag, the
+// TICKTICK_PROFILE environment variable or the current_profile setting
+func SelectProfile(flag string) error {
+	name := flag
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	if name == "" {
+		name = CurrentConfig().CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	name = strings.ToLower(name)
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile returns the name of the profile in use
+func ActiveProfile() string {
+	return activeProfile
+}
+
+// ValidateProfileName checks that name can be used as a config key
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的配置档案名称：%s（只能包含小写字母、数字、- 和 _）", name)
+	}
+	return nil
+}
+
+// Profiles returns the names of all configured profiles
+func Profiles() []string {
+	names := []string{}
+	for name := range CurrentConfig().Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileExists reports whether a profile has been configured
+func ProfileExists(name string) bool {
+	return slices.Contains(Profiles(), name)
+}
+
+// AddProfile creates an empty profile, optionally copying the client
+// credentials and region (but not the tokens) of another profile
+func AddProfile(name, from string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if ProfileExists(name) {
+		return fmt.Errorf("配置档案已存在：%s", name)
+	}
+
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	profile := map[string]any{}
+	if from != "" {
+		if !ProfileExists(from) {
+			return fmt.Errorf("配置档案不存在：%s", from)
+		}
+		configMu.RLock()
+		for _, key := range []string{"client_id", "redirect_uri", "region", "client_secret_cmd"} {
+			if value := viper.Get("profiles." + from + "." + key); value != nil {
+				profile[key] = value
+			}
+		}
+		configMu.RUnlock()
+	}
+	setNested(settings, []string{"profiles", name}, profile)
+	if err := writeConfigFile(settings); err != nil {
+		return err
+	}
+
+	if from == "" {
+		return nil
+	}
+	secret, source, err := getSecret(from, "client_secret")
+	if err != nil || secret == "" || source == "env" || source == "command" {
+		return err
+	}
+	return setSecret(name, "client_secret", secret)
+}
+
+// UseProfile makes name the profile used when none is selected explicitly
+func UseProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("配置档案不存在：%s", name)
+	}
+	if err := SaveConfig("current_profile", name); err != nil {
+		return err
+	}
+	activeProfile = name
+	return nil
+}
+
+// RemoveProfile deletes a profile together with its credentials
+func RemoveProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("配置档案不存在：%s", name)
+	}
+
+	for _, key := range secretKeys {
+		if err := deleteSecret(name, key); err != nil {
+			return err
+		}
+	}
+
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	deleteNested(settings, []string{"profiles", name})
+	if CurrentConfig().CurrentProfile == name {
+		delete(settings, "current_profile")
+	}
+	return writeConfigFile(settings)
+}
+
+// MigrateLegacyConfig moves per-profile settings written before profiles
+// existed from the top level of the config file into the default profile
+func MigrateLegacyConfig() error {
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	moved := false
+	for _, key := range append(profileKeys, "token") {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		target := key
+		if key == "token" {
+			target = "access_token"
+		}
+		path := []string{"profiles", DefaultProfile, target}
+		if !hasNested(settings, path) {
+			setNested(settings, path, value)
+		}
+		delete(settings, key)
+		moved = true
+	}
+
+	if !moved {
+		return nil
+	}
+	return writeConfigFile(settings)
+}
+
+// ProfileKeys returns the settings that are stored per profile
+func ProfileKeys() []string {
+	return slices.Clone(profileKeys)
+}
+
+func isProfileKey(key string) bool {
+	return slices.Contains(profileKeys, key)
+}
+
+// configKey maps a setting to where it is stored for the active profile
+func configKey(key string) string {
+	if isProfileKey(key) {
+		return "profiles." + activeProfile + "." + key
+	}
+	return key
+}
+
+// GetString returns any setting by name, as text, for display. Per-profile
+// settings come from the active profile; a value set through an environment
+// variable for the plain key takes precedence over the profile. Code that
+// needs a particular setting uses CurrentConfig or CurrentProfile instead.
+func GetString(key string) string {
+	if slices.Contains(secretKeys, key) {
+		value, _ := GetSecret(key)
+		return value
+	}
+	configMu.RLock()
+	defer configMu.RUnlock()
+	if isProfileKey(key) {
+		if value := viper.GetString(key); value != "" {
+			return value
+		}
+	}
+	return viper.GetString(configKey(key))
+}
+
+// IsSet reports whether a setting has a value for the active profile
+func IsSet(key string) bool {
+	if slices.Contains(secretKeys, key) {
+		return GetString(key) != ""
+	}
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return (isProfileKey(key) && viper.IsSet(key)) || viper.IsSet(configKey(key))
+}
+
+// profileDir returns the per-profile subdirectory of base. The default
+// profile uses base itself so that data from before profiles keeps working.
+func profileDir(base, profile string) string {
+	if profile == DefaultProfile {
+		return base
+	}
+	return filepath.Join(base, "profiles", profile)
+}
+
+func hasNested(m map[string]any, path []string) bool {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			return false
+		}
+		m = next
+	}
+	_, ok := m[path[len(path)-1]]
+	return ok
+}
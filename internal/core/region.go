@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"ticktick-tui/internal/auth"
+	"ticktick-tui/internal/client"
+)
+
+const DefaultRegion = "ticktick"
+
+// Region describes the endpoints of one TickTick service
+type Region struct {
+	APIURL   string
+	AuthURL  string
+	TokenURL string
+}
+
+var regions = map[string]Region{
+	"ticktick": {
+		APIURL:   client.BaseURL,
+		AuthURL:  auth.AuthURL,
+		TokenURL: auth.TokenURL,
+	},
+	"dida365": {
+		APIURL:   "https://api.dida365.com",
+		AuthURL:  "https://dida365.com/oauth/authorize",
+		TokenURL: "https://dida365.com/oauth/token",
+	},
+}
+
+// Regions returns the names of all supported regions
+func Regions() []string {
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// currentRegion returns the region of the active profile
+func currentRegion() (Region, error) {
+	name := CurrentProfile().Region
+	if name == "" {
+		name = DefaultRegion
+	}
+	region, ok := regions[name]
+	if !ok {
+		return Region{}, fmt.Errorf("未知的区域：%s", name)
+	}
+	return region, nil
+}
+
+// oauthClient builds an OAuth client from the active profile
+func oauthClient() *auth.OAuthClient {
+	profile := CurrentProfile()
+	c := &auth.OAuthClient{
+		ClientID:     profile.ClientID,
+		ClientSecret: secret("client_secret"),
+		RedirectURI:  profile.RedirectURI,
+	}
+	if region, err := currentRegion(); err == nil {
+		c.AuthEndpoint = region.AuthURL
+		c.TokenEndpoint = region.TokenURL
+	}
+	return c
+}
@@ -0,0 +1,325 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"ticktick-tui/internal/secrets"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// SecretStoreConfig keeps secrets in plain text in the config file
+	SecretStoreConfig = "config"
+	// SecretStoreFile keeps secrets in a separate encrypted file
+	SecretStoreFile = "file"
+
+	// SecretsPassphraseEnv holds the passphrase of the encrypted secrets file
+	SecretsPassphraseEnv = "TICKTICK_SECRETS_PASSPHRASE"
+)
+
+// secretKeys are never printed in full and are stored in the secret store
+var secretKeys = []string{
+	"client_secret",
+	"access_token",
+	"refresh_token",
+	"ics_token",
+}
+
+// ErrSecretsLocked is returned when the encrypted secrets file is used but no
+// passphrase is available
+var ErrSecretsLocked = fmt.Errorf("加密的凭据文件需要密码：请设置 %s 或 secrets_key_file，或在终端中运行", SecretsPassphraseEnv)
+
+var (
+	passphrasePrompt func(create bool) ([]byte, error)
+
+	secretFile     *secrets.FileStore
+	secretFileErr  error
+	secretFileOpen bool
+)
+
+// SetPassphrasePrompt sets the function used to ask for the passphrase of
+// the encrypted secrets file when neither a key file nor the environment
+// provide it. create is true when a new file is about to be created.
+func SetPassphrasePrompt(prompt func(create bool) ([]byte, error)) {
+	passphrasePrompt = prompt
+}
+
+// IsSecretKey reports whether a setting holds a secret. Nested keys such as
+// profiles.work.access_token are recognized by their last segment.
+func IsSecretKey(key string) bool {
+	return slices.Contains(secretKeys, key[strings.LastIndex(key, ".")+1:])
+}
+
+// RedactSetting returns value, hidden if key holds a secret
+func RedactSetting(key, value string) string {
+	if IsSecretKey(key) {
+		return secrets.Redact(value)
+	}
+	return value
+}
+
+// SecretStore returns the configured backend that secrets are written to
+func SecretStore() string {
+	if store := CurrentConfig().SecretStore; store != "" {
+		return store
+	}
+	return SecretStoreConfig
+}
+
+// SecretsFile returns the path of the encrypted secrets file
+func SecretsFile() string {
+	if path := CurrentConfig().SecretsFile; path != "" {
+		return path
+	}
+	path := ConfigFile()
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".secrets"
+}
+
+// UnlockSecrets opens the encrypted secrets file if it is in use, asking for
+// the passphrase if necessary. Callers that need secrets should call it
+// early so a wrong passphrase is reported instead of looking like missing
+// credentials.
+func UnlockSecrets() error {
+	if SecretStore() != SecretStoreFile {
+		return nil
+	}
+	_, err := openSecretFile()
+	return err
+}
+
+func openSecretFile() (*secrets.FileStore, error) {
+	if secretFileOpen {
+		return secretFile, secretFileErr
+	}
+	secretFileOpen = true
+
+	path := SecretsFile()
+	passphrase, err := secretsPassphrase(path)
+	if err == nil {
+		secretFile, err = secrets.OpenFile(path, passphrase)
+	}
+	if err != nil {
+		secretFileErr = fmt.Errorf("无法打开加密的凭据文件：%w", err)
+	}
+	return secretFile, secretFileErr
+}
+
+func secretsPassphrase(path string) ([]byte, error) {
+	if keyFile := CurrentConfig().SecretsKeyFile; keyFile != "" {
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimSpace(string(key))), nil
+	}
+	if passphrase := os.Getenv(SecretsPassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if passphrasePrompt == nil {
+		return nil, ErrSecretsLocked
+	}
+
+	_, err := os.Stat(path)
+	return passphrasePrompt(errors.Is(err, os.ErrNotExist))
+}
+
+// configSecretStore reads and writes secrets of a profile in the config file
+type configSecretStore struct {
+	profile string
+}
+
+func (s configSecretStore) Name() string {
+	return SecretStoreConfig
+}
+
+func (s configSecretStore) path(key string) []string {
+	return []string{"profiles", s.profile, key}
+}
+
+func (s configSecretStore) Get(key string) (string, error) {
+	configMu.RLock()
+	value := viper.GetString(strings.Join(s.path(key), "."))
+	configMu.RUnlock()
+	if value == "" {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func (s configSecretStore) Set(key, value string) error {
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	setNested(settings, s.path(key), value)
+	return writeConfigFile(settings)
+}
+
+func (s configSecretStore) Delete(key string) error {
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if !hasNested(settings, s.path(key)) {
+		return nil
+	}
+	deleteNested(settings, s.path(key))
+	return writeConfigFile(settings)
+}
+
+// fileSecretStore scopes the shared encrypted file to one profile
+type fileSecretStore struct {
+	file    *secrets.FileStore
+	profile string
+}
+
+func (s fileSecretStore) Name() string {
+	return SecretStoreFile
+}
+
+func (s fileSecretStore) Get(key string) (string, error) {
+	return s.file.Get(s.profile + "." + key)
+}
+
+func (s fileSecretStore) Set(key, value string) error {
+	return s.file.Set(s.profile+"."+key, value)
+}
+
+func (s fileSecretStore) Delete(key string) error {
+	return s.file.Delete(s.profile + "." + key)
+}
+
+// secretStores returns the places secrets of profile are looked up in, in
+// order of precedence. The last but one store is the one written to; the
+// config file always comes last so that secrets written before switching to
+// the encrypted file keep working until they are migrated.
+func secretStores(profile string) ([]secrets.Store, error) {
+	stores := []secrets.Store{
+		secrets.EnvStore{Prefix: EnvPrefix + "_"},
+		secrets.CommandStore{Command: CurrentConfig().Profile(profile).secretCommand},
+	}
+
+	if SecretStore() == SecretStoreFile {
+		file, err := openSecretFile()
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, fileSecretStore{file: file, profile: profile})
+	}
+	return append(stores, configSecretStore{profile: profile}), nil
+}
+
+// writableSecretStore returns the store new secrets of profile go to
+func writableSecretStore(profile string) (secrets.Store, error) {
+	switch SecretStore() {
+	case SecretStoreConfig:
+		return configSecretStore{profile: profile}, nil
+	case SecretStoreFile:
+		file, err := openSecretFile()
+		if err != nil {
+			return nil, err
+		}
+		return fileSecretStore{file: file, profile: profile}, nil
+	default:
+		return nil, fmt.Errorf("未知的 secret_store：%s（可选值：%s、%s）", SecretStore(), SecretStoreConfig, SecretStoreFile)
+	}
+}
+
+// getSecret looks a secret of profile up and reports where it came from
+func getSecret(profile, key string) (string, string, error) {
+	stores, err := secretStores(profile)
+	if err != nil {
+		return "", "", err
+	}
+	value, store, err := secrets.Lookup(key, stores...)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", store.Name(), err
+	}
+	return value, store.Name(), nil
+}
+
+func setSecret(profile, key, value string) error {
+	store, err := writableSecretStore(profile)
+	if err != nil {
+		return err
+	}
+	if err := store.Set(key, value); err != nil {
+		return err
+	}
+	// Don't leave a stale plain text copy behind
+	if store.Name() != SecretStoreConfig {
+		return configSecretStore{profile: profile}.Delete(key)
+	}
+	return nil
+}
+
+func deleteSecret(profile, key string) error {
+	if SecretStore() == SecretStoreFile {
+		file, err := openSecretFile()
+		if err != nil {
+			return err
+		}
+		if err := (fileSecretStore{file: file, profile: profile}).Delete(key); err != nil {
+			return err
+		}
+	}
+	return configSecretStore{profile: profile}.Delete(key)
+}
+
+// GetSecret returns a secret of the active profile
+func GetSecret(key string) (string, error) {
+	value, _, err := getSecret(activeProfile, key)
+	return value, err
+}
+
+// secret returns a secret of the active profile, or "" if it is not
+// available. UnlockSecrets reports why.
+func secret(key string) string {
+	value, _ := GetSecret(key)
+	return value
+}
+
+// SecretSource returns where a secret of the active profile comes from:
+// env, command, file or config. It is empty if the secret is not set.
+func SecretSource(key string) string {
+	_, source, _ := getSecret(activeProfile, key)
+	return source
+}
+
+// SecretEnvVar returns the environment variable that overrides a secret
+func SecretEnvVar(key string) string {
+	return secrets.EnvStore{Prefix: EnvPrefix + "_"}.Variable(key)
+}
+
+// MigrateSecrets moves secrets of all profiles that are still in plain text
+// in the config file into the configured secret store. It returns the number
+// of secrets moved.
+func MigrateSecrets() (int, error) {
+	if SecretStore() == SecretStoreConfig {
+		return 0, nil
+	}
+
+	moved := 0
+	for _, profile := range Profiles() {
+		plain := configSecretStore{profile: profile}
+		for _, key := range secretKeys {
+			value, err := plain.Get(key)
+			if errors.Is(err, secrets.ErrNotFound) {
+				continue
+			}
+			if err := setSecret(profile, key, value); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+	return moved, nil
+}
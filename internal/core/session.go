@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"ticktick-tui/internal/auth"
+	"time"
+)
+
+// Pending logins older than this have to be started again
+const authSessionMaxAge = 30 * time.Minute
+
+// ErrNoAuthSession is returned when a code arrives without a pending login
+var ErrNoAuthSession = errors.New("没有进行中的登录，请先运行 auth login")
+
+// ErrMissingState is returned for a bare authorization code, whose state
+// can't be checked against the pending login
+var ErrMissingState = errors.New("缺少state参数，请粘贴浏览器跳转到的完整URL，而不仅仅是授权码")
+
+func authSessionPath() (string, error) {
+	return authSessionPathFor(activeProfile)
+}
+
+func authSessionPathFor(profile string) (string, error) {
+	dir, err := stateDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "auth_session.json"), nil
+}
+
+// newAuthSession starts a login and persists it, so that the code can be
+// exchanged by a later `auth token` invocation
+func newAuthSession() (*auth.Session, error) {
+	session, err := auth.NewSession(CurrentProfile().PKCE())
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := authSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// loadAuthSession returns the pending login
+func loadAuthSession() (*auth.Session, error) {
+	path, err := authSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoAuthSession
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session auth.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("读取登录会话失败：%w", err)
+	}
+	if session.Expired(authSessionMaxAge) {
+		clearAuthSession()
+		return nil, fmt.Errorf("登录会话已过期，请重新运行 auth login")
+	}
+	return &session, nil
+}
+
+func clearAuthSession() {
+	if path, err := authSessionPath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// ParseAuthResponse accepts the whole redirect URL or its query string and
+// returns the code and state. A bare code is returned with an empty state.
+func ParseAuthResponse(input string) (code, state string) {
+	input = strings.TrimSpace(input)
+	if !strings.Contains(input, "code=") {
+		return input, ""
+	}
+
+	query := input
+	if u, err := url.Parse(input); err == nil && u.RawQuery != "" {
+		query = u.RawQuery
+	}
+	values, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return input, ""
+	}
+	return values.Get("code"), values.Get("state")
+}
@@ -0,0 +1,223 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// ErrNoRefreshToken is returned when the token cannot be renewed automatically
+var ErrNoRefreshToken = errors.New("没有可用的刷新令牌，请重新运行 auth login")
+
+// TokenInfo describes the stored access token
+type TokenInfo struct {
+	HasToken        bool
+	HasRefreshToken bool
+	Scope           string
+	ExpiresAt       time.Time // zero if unknown
+}
+
+// Expired reports whether the token is known to have expired
+func (t TokenInfo) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// SaveToken stores an OAuth token together with its expiry, scope and
+// refresh token
+func SaveToken(token *models.OAuthToken) error {
+	if err := SaveConfig("access_token", token.AccessToken); err != nil {
+		return err
+	}
+
+	expiresAt := ""
+	if t := tokenExpiry(token); !t.IsZero() {
+		expiresAt = t.Format(time.RFC3339)
+	}
+	if err := SaveConfig("token_expires_at", expiresAt); err != nil {
+		return err
+	}
+	if err := SaveConfig("token_scope", token.Scope); err != nil {
+		return err
+	}
+
+	// Refresh responses may omit the refresh token, keep the old one then
+	if token.RefreshToken != "" {
+		if err := SaveConfig("refresh_token", token.RefreshToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTokenInfo returns what is known about the stored token
+func GetTokenInfo() TokenInfo {
+	info := TokenInfo{
+		HasToken:        accessToken() != "",
+		HasRefreshToken: secret("refresh_token") != "",
+		Scope:           CurrentProfile().TokenScope,
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, CurrentProfile().TokenExpiresAt); err == nil {
+		info.ExpiresAt = expiresAt
+	}
+	return info
+}
+
+// tokenExpiry returns when a token that was just issued expires, or zero if
+// unknown
+func tokenExpiry(token *models.OAuthToken) time.Time {
+	if token.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+}
+
+// refreshedToken is the successor of an access token that was renewed
+type refreshedToken struct {
+	token     *models.OAuthToken
+	expiresAt time.Time
+}
+
+var (
+	// refreshMu lets only one client at a time renew the token; a refresh
+	// token may be invalidated by its first use
+	refreshMu sync.Mutex
+	// refreshed maps renewed access tokens to their successors, so that
+	// clients that still use the old one don't renew it again
+	refreshed = map[string]refreshedToken{}
+)
+
+// refreshAccessToken renews stale, the access token a client was rejected
+// with, unless another client did so already. New tokens are handed to save.
+func refreshAccessToken(stale string, save func(*models.OAuthToken) error) (refreshedToken, error) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	if r, ok := refreshed[stale]; ok {
+		return r, nil
+	}
+
+	refreshToken := secret("refresh_token")
+	if refreshToken == "" {
+		return refreshedToken{}, ErrNoRefreshToken
+	}
+	token, err := oauthClient().RefreshToken(refreshToken)
+	if err != nil {
+		return refreshedToken{}, fmt.Errorf("刷新访问令牌失败：%w", err)
+	}
+	r := refreshedToken{token: token, expiresAt: tokenExpiry(token)}
+	refreshed[stale] = r
+	return r, save(token)
+}
+
+// RefreshToken renews the stored access token with the stored refresh token
+// and saves the new one
+func RefreshToken() (*models.OAuthToken, error) {
+	r, err := refreshAccessToken(accessToken(), SaveToken)
+	return r.token, err
+}
+
+// Logout removes the tokens and any pending login of profile and clears its
+// cache. The OAuth client credentials are kept so that logging in again only
+// takes `auth login`.
+func Logout(profile string) error {
+	for _, key := range []string{"access_token", "refresh_token"} {
+		if err := deleteSecret(profile, key); err != nil {
+			return err
+		}
+	}
+
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"token_expires_at", "token_scope"} {
+		deleteNested(settings, []string{"profiles", profile, key})
+	}
+	if err := writeConfigFile(settings); err != nil {
+		return err
+	}
+
+	if path, err := authSessionPathFor(profile); err == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	dir, err := cacheDir(profile)
+	if err != nil {
+		return err
+	}
+	return clearCache(dir)
+}
+
+// clearCache empties a profile's cache directory. The default profile's
+// directory also holds the other profiles' caches, those are left alone.
+func clearCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() == "profiles" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func accessToken() string {
+	return secret("access_token")
+}
+
+// NewClient creates an API client for the stored token that renews the
+// token automatically when a refresh token is available. It returns nil if
+// there is no token.
+func NewClient() *client.Client {
+	return NewClientWithTokenSaver(SaveToken)
+}
+
+// NewClientWithTokenSaver is NewClient with renewed tokens handed to save
+// instead of being saved right away. save is called from the goroutine of
+// the request, programs that write the configuration from one goroutine
+// only pass the token on to it.
+func NewClientWithTokenSaver(save func(*models.OAuthToken) error) *client.Client {
+	token := accessToken()
+	if token == "" {
+		return nil
+	}
+
+	c := client.NewClient(token)
+	if region, err := currentRegion(); err == nil {
+		c.SetBaseURL(region.APIURL)
+	}
+
+	// A token from the environment or a command can't be replaced
+	info := GetTokenInfo()
+	source := SecretSource("access_token")
+	if info.HasRefreshToken && (source == SecretStoreConfig || source == SecretStoreFile) {
+		// The client holds its lock while refreshing, so current is safe
+		current := token
+		c.SetTokenRefresher(func() (string, time.Time, error) {
+			r, err := refreshAccessToken(current, save)
+			if r.token == nil {
+				return "", time.Time{}, err
+			}
+			if err != nil {
+				// The new token still works until the program exits
+				slog.Warn("saving refreshed token failed", "error", err)
+			}
+			current = r.token.AccessToken
+			return current, r.expiresAt, nil
+		}, info.ExpiresAt)
+	}
+	return c
+}
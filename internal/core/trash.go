@@ -0,0 +1,160 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/models"
+	"ticktick-tui/internal/trash"
+	"time"
+)
+
+// DefaultTrashRetentionDays is used when trash_retention_days is not set
+const DefaultTrashRetentionDays = 30
+
+// OpenTrash opens the local trash, expiring entries older than the
+// configured retention period
+func OpenTrash() (*trash.Store, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	days := CurrentConfig().TrashRetention()
+	return trash.Open(filepath.Join(dir, "trash"), time.Duration(days)*24*time.Hour)
+}
+
+// DeleteTask snapshots a task into the trash and then deletes it. The
+// snapshot is discarded again if the delete does not go through. The
+// returned entry can be passed to RestoreFromTrash.
+func DeleteTask(c *client.Client, projectID, taskID string) (*trash.Entry, error) {
+	task, err := c.GetTask(projectID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败：%w", err)
+	}
+	return TrashTask(c, task)
+}
+
+// TrashTask is DeleteTask for a task that was fetched already
+func TrashTask(c *client.Client, task *models.Task) (*trash.Entry, error) {
+	return moveToTrash(&trash.Entry{Kind: trash.KindTask, Task: task}, func() error {
+		return c.DeleteTask(task.ProjectID, task.ID)
+	})
+}
+
+// DeleteProject snapshots a project with all of its tasks into the trash and
+// then deletes it
+func DeleteProject(c *client.Client, projectID string) (*trash.Entry, error) {
+	data, err := c.GetProjectData(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目数据失败：%w", err)
+	}
+	return TrashProject(c, data)
+}
+
+// TrashProject is DeleteProject for project data that was fetched already
+func TrashProject(c *client.Client, data *models.ProjectData) (*trash.Entry, error) {
+	return moveToTrash(&trash.Entry{Kind: trash.KindProject, Project: data}, func() error {
+		return c.DeleteProject(data.Project.ID)
+	})
+}
+
+func moveToTrash(entry *trash.Entry, del func() error) (*trash.Entry, error) {
+	store, err := OpenTrash()
+	if err != nil {
+		return nil, fmt.Errorf("打开回收站失败：%w", err)
+	}
+	if err := store.Add(entry); err != nil {
+		return nil, fmt.Errorf("保存到回收站失败：%w", err)
+	}
+
+	if err := del(); err != nil {
+		store.Remove(entry.ID)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RestoreFromTrash recreates a trashed task or project through the API and
+// removes it from the trash. Restored objects get new IDs. Progress is kept
+// in the entry, so a restore that failed halfway can simply be retried.
+func RestoreFromTrash(c *client.Client, id string) (*trash.Entry, error) {
+	store, err := OpenTrash()
+	if err != nil {
+		return nil, fmt.Errorf("打开回收站失败：%w", err)
+	}
+	entry, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch entry.Kind {
+	case trash.KindTask:
+		if err := restoreTasks(c, store, entry, []models.Task{*entry.Task}, entry.Task.ProjectID); err != nil {
+			return nil, err
+		}
+
+	case trash.KindProject:
+		if entry.RestoredProjectID == "" {
+			project := entry.Project.Project
+			project.ID = ""
+			created, err := c.CreateProject(&project)
+			if err != nil {
+				return nil, err
+			}
+			entry.RestoredProjectID = created.ID
+			if err := store.Update(entry); err != nil {
+				// Without the ID a retry would create the project again
+				c.DeleteProject(created.ID)
+				return nil, fmt.Errorf("保存恢复进度失败：%w", err)
+			}
+		}
+		if err := restoreTasks(c, store, entry, entry.Project.Tasks, entry.RestoredProjectID); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("未知的回收站条目类型：%s", entry.Kind)
+	}
+
+	return entry, store.Remove(entry.ID)
+}
+
+// restoreTasks recreates tasks in projectID, skipping those a previous
+// attempt created already, and completes the ones that were completed
+func restoreTasks(c *client.Client, store *trash.Store, entry *trash.Entry, tasks []models.Task, projectID string) error {
+	for i, task := range tasks {
+		if i >= len(entry.RestoredTaskIDs) {
+			created, err := c.CreateTask(newTaskFromSnapshot(task, projectID))
+			if err != nil {
+				return fmt.Errorf("恢复任务 %s 失败：%w", task.Title, err)
+			}
+			entry.RestoredTaskIDs = append(entry.RestoredTaskIDs, created.ID)
+			if err := store.Update(entry); err != nil {
+				c.DeleteTask(projectID, created.ID)
+				return fmt.Errorf("保存恢复进度失败：%w", err)
+			}
+		}
+
+		// The API ignores the status of new tasks
+		if task.Status == models.StatusCompleted {
+			if err := c.CompleteTask(projectID, entry.RestoredTaskIDs[i]); err != nil {
+				return fmt.Errorf("完成任务 %s 失败：%w", task.Title, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newTaskFromSnapshot prepares a trashed task for creation in projectID
+func newTaskFromSnapshot(task models.Task, projectID string) *models.Task {
+	task.ID = ""
+	task.ProjectID = projectID
+	items := make([]models.ChecklistItem, len(task.Items))
+	for i, item := range task.Items {
+		item.ID = ""
+		items[i] = item
+	}
+	task.Items = items
+	return &task
+}
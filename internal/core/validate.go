@@ -0,0 +1,304 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"ticktick-tui/internal/logging"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ValidationError describes a problem with one setting
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s：%s", e.Key, e.Message)
+}
+
+// validators check the value of a setting. Settings without an entry accept
+// any value of their type.
+var validators = map[string]func(string) error{
+	"secret_store":         oneOf(SecretStoreConfig, SecretStoreFile),
+	"current_profile":      ValidateProfileName,
+	"region":               func(value string) error { return oneOf(Regions()...)(value) },
+	"redirect_uri":         validateRedirectURI,
+	"token_expires_at":     validateTimestamp,
+	"trash_retention_days": nonNegative,
+	"log.level":            oneOf(logging.Levels...),
+	"log.max_size":         nonNegative,
+	"log.max_backups":      nonNegative,
+	"tui.keymap":           oneOf(KeymapPresets...),
+	"tui.refresh_interval": func(value string) error {
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return errors.New("必须是不小于0的时长，例如 30s、5m")
+		}
+		return nil
+	},
+}
+
+// colorNames are the palette entries tui.colors can replace
+var colorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "light_gray",
+	"dark_gray", "bright_red", "bright_green", "bright_yellow", "bright_blue",
+	"bright_magenta", "bright_cyan", "white",
+}
+
+// colorPattern accepts what lipgloss understands: ANSI color numbers and
+// hex RGB values
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+func validateColor(value string) error {
+	if !colorPattern.MatchString(value) {
+		return errors.New("必须是ANSI颜色编号（0-255）或十六进制颜色，例如 #5f87ff")
+	}
+	return nil
+}
+
+// colorKey returns the palette entry a tui.colors.<name> key refers to
+func colorKey(key string) (string, bool) {
+	name, ok := strings.CutPrefix(key, "tui.colors.")
+	return name, ok && slices.Contains(colorNames, name)
+}
+
+// KeymapPresets are the key bindings tui.keymap can select
+var KeymapPresets = []string{"default", "vim", "emacs"}
+
+// keyActions are the TUI actions tui.keys can bind
+var keyActions = []string{
+	"quit", "up", "down", "top", "bottom", "page_up", "page_down", "open",
+	"back", "new", "edit", "delete", "complete", "undo", "logout", "help", "search",
+	"next_pane", "previous_pane", "open_browser", "board", "previous_column", "next_column",
+	"move_left", "move_right",
+	"next_field", "previous_field", "save", "cancel", "confirm",
+	"previous_option", "next_option", "toggle", "previous_day", "next_day",
+	"previous_month", "next_month", "earlier", "later", "today", "clear",
+}
+
+// keysKey returns the action a tui.keys.<action> key refers to
+func keysKey(key string) (string, bool) {
+	name, ok := strings.CutPrefix(key, "tui.keys.")
+	return name, ok && slices.Contains(keyActions, name)
+}
+
+// validateKeys checks the keys bound to an action, a list of key names such
+// as ctrl+n or sequences such as "g g"
+func validateKeys(value any) error {
+	keys, ok := value.([]any)
+	if !ok {
+		return errors.New("必须是按键的列表，例如 [j, down]")
+	}
+	for _, k := range keys {
+		if s, ok := k.(string); !ok || strings.TrimSpace(s) == "" {
+			return errors.New("按键不能为空")
+		}
+	}
+	return nil
+}
+
+func nonNegative(value string) error {
+	if strings.HasPrefix(value, "-") {
+		return errors.New("不能为负数")
+	}
+	return nil
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("必须是 %s 之一", strings.Join(values, "、"))
+		}
+		return nil
+	}
+}
+
+func validateRedirectURI(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("必须是 http 或 https 开头的完整URL，例如 http://localhost:8080/callback")
+	}
+	return nil
+}
+
+func validateTimestamp(value string) error {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return errors.New("必须是 RFC 3339 格式的时间")
+	}
+	return nil
+}
+
+// IsKnownKey reports whether key is a setting that can be configured
+func IsKnownKey(key string) bool {
+	if _, ok := colorKey(key); ok {
+		return true
+	}
+	if _, ok := keysKey(key); ok {
+		return true
+	}
+	return isProfileKey(key) || (key != "profiles" && key != "tui.colors" && key != "tui.keys" && slices.Contains(sharedKeys(), key))
+}
+
+// validateSetting checks a single value before it is saved
+func validateSetting(key, value string) error {
+	if !IsKnownKey(key) {
+		return &ValidationError{Key: key, Message: "未知的配置项"}
+	}
+	if _, ok := colorKey(key); ok {
+		if err := validateColor(value); err != nil {
+			return &ValidationError{Key: key, Message: err.Error()}
+		}
+		return nil
+	}
+	if validate, ok := validators[key]; ok && value != "" {
+		if err := validate(value); err != nil {
+			return &ValidationError{Key: key, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// ValidateConfig checks the configuration file in use
+func ValidateConfig() error {
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	return validateSettings(settings)
+}
+
+// ValidateConfigFile checks the configuration file at path without loading it
+func ValidateConfigFile(path string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configType(path))
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	return validateSettings(v.AllSettings())
+}
+
+// validateSettings reports every unknown key and invalid value in settings
+func validateSettings(settings map[string]any) error {
+	var errs []error
+
+	shared := map[string]any{}
+	for key, value := range settings {
+		if key != "profiles" {
+			flatten(shared, key, value)
+		}
+	}
+	keys := make([]string, 0, len(shared))
+	for key := range shared {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := validateValue(key, shared[key], IsKnownKey(key)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	profiles, ok := settings["profiles"].(map[string]any)
+	if _, set := settings["profiles"]; set && !ok {
+		errs = append(errs, &ValidationError{Key: "profiles", Message: "必须是配置档案名称到配置的映射"})
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ValidateProfileName(name); err != nil {
+			errs = append(errs, &ValidationError{Key: "profiles." + name, Message: err.Error()})
+			continue
+		}
+		profile, ok := profiles[name].(map[string]any)
+		if !ok {
+			if profiles[name] != nil {
+				errs = append(errs, &ValidationError{Key: "profiles." + name, Message: "必须是配置项的映射"})
+			}
+			continue
+		}
+
+		keys := make([]string, 0, len(profile))
+		for key := range profile {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := validateValue(key, profile[key], isProfileKey(key)); err != nil {
+				err.Key = "profiles." + name + "." + key
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if current, ok := settings["current_profile"].(string); ok && current != "" && current != DefaultProfile {
+		if _, exists := profiles[current]; !exists {
+			errs = append(errs, &ValidationError{Key: "current_profile", Message: "配置档案不存在：" + current})
+		}
+	}
+
+	// Catch type errors, e.g. text where a number is expected
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err == nil {
+		var cfg Config
+		if err := v.Unmarshal(&cfg); err != nil {
+			errs = append(errs, fmt.Errorf("配置类型错误：%w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// flatten adds value to m under dotted keys, descending into sections
+func flatten(m map[string]any, key string, value any) {
+	section, ok := value.(map[string]any)
+	if !ok {
+		m[key] = value
+		return
+	}
+	for name, value := range section {
+		flatten(m, key+"."+name, value)
+	}
+}
+
+func validateValue(key string, value any, known bool) *ValidationError {
+	if !known {
+		return &ValidationError{Key: key, Message: "未知的配置项"}
+	}
+	if value == nil {
+		return nil
+	}
+	if _, isKeys := keysKey(key); isKeys {
+		if err := validateKeys(value); err != nil {
+			return &ValidationError{Key: key, Message: err.Error()}
+		}
+		return nil
+	}
+	validate, ok := validators[key]
+	if _, isColor := colorKey(key); isColor {
+		validate, ok = validateColor, true
+	}
+	if !ok {
+		return nil
+	}
+	s := fmt.Sprint(value)
+	if s == "" {
+		return nil
+	}
+	if err := validate(s); err != nil {
+		return &ValidationError{Key: key, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	writeMu sync.Mutex
+	// lastWrite is the checksum of what this process last wrote to the
+	// configuration file, so that its own changes are not reported as reloads
+	lastWrite []byte
+)
+
+// ownWrite runs write, which changes the configuration file, and records
+// the result as written by this process. Change notifications wait for it
+// to finish.
+func ownWrite(write func() error) error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	err := write()
+	lastWrite = configChecksum(ConfigFile())
+	return err
+}
+
+func configChecksum(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// reloadDelay lets editors finish writing before the file is read; saving
+// often shows up as several events, e.g. truncate and write
+const reloadDelay = 200 * time.Millisecond
+
+// WatchConfig calls onChange whenever the file is changed by something
+// else, e.g. an editor. onChange is called from a separate goroutine and
+// must not touch the configuration; the goroutine that uses it applies the
+// change with ReloadConfig.
+func WatchConfig(onChange func()) error {
+	path := ConfigFile()
+	if path == "" {
+		return nil
+	}
+	path = filepath.Clean(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// Editors often replace the file, which removes a watch on the file itself
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	changed := func() {
+		writeMu.Lock()
+		sum := configChecksum(path)
+		seen := sum != nil && bytes.Equal(sum, lastWrite)
+		lastWrite = sum
+		writeMu.Unlock()
+		// Written by this process, or content that was already handled
+		if !seen {
+			onChange()
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, changed)
+				mu.Unlock()
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ReloadConfig applies the configuration file after WatchConfig reported a
+// change. A file that fails validation is not applied; the previous
+// configuration stays in effect.
+func ReloadConfig() error {
+	if err := ValidateConfig(); err != nil {
+		return err
+	}
+	return readInConfig(ConfigFile())
+}
@@ -0,0 +1,156 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// Format identifies a supported export format
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatTodoTxt  Format = "todotxt"
+	FormatMarkdown Format = "markdown"
+	FormatOrg      Format = "org"
+	FormatICS      Format = "ics"
+)
+
+// Formats lists all supported export formats
+var Formats = []Format{
+	FormatJSON,
+	FormatTodoTxt,
+	FormatMarkdown,
+	FormatOrg,
+	FormatICS,
+}
+
+// Write renders the given projects in the requested format. Projects and
+// tasks are sorted first so that exporting unchanged data always produces
+// identical output.
+func Write(w io.Writer, format Format, data []models.ProjectData) error {
+	data = sortProjectData(data)
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case FormatTodoTxt:
+		return writeTodoTxt(w, data)
+	case FormatMarkdown:
+		return writeMarkdown(w, data)
+	case FormatOrg:
+		return writeOrg(w, data)
+	case FormatICS:
+		return WriteICS(w, "TickTick", data)
+	}
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+// sortProjectData returns a copy ordered by project and task sort order,
+// falling back to names and IDs to break ties
+func sortProjectData(data []models.ProjectData) []models.ProjectData {
+	sorted := make([]models.ProjectData, len(data))
+	copy(sorted, data)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Project, sorted[j].Project
+		if isInbox(a) != isInbox(b) {
+			return isInbox(a)
+		}
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+
+	for i := range sorted {
+		tasks := make([]models.Task, len(sorted[i].Tasks))
+		copy(tasks, sorted[i].Tasks)
+		sort.SliceStable(tasks, func(i, j int) bool {
+			if tasks[i].SortOrder != tasks[j].SortOrder {
+				return tasks[i].SortOrder < tasks[j].SortOrder
+			}
+			return tasks[i].ID < tasks[j].ID
+		})
+		sorted[i].Tasks = tasks
+	}
+
+	return sorted
+}
+
+// isInbox reports whether project is the Inbox, which has no name. The Inbox
+// comes first and its tasks are exported without a project.
+func isInbox(project models.Project) bool {
+	return project.Name == ""
+}
+
+// escapeName turns a project or tag name into a single word: spaces become
+// underscores, and literal underscores and backslashes are escaped with a
+// backslash so that the importer can restore the name
+func escapeName(name string) string {
+	return strings.NewReplacer(`\`, `\\`, "_", `\_`, " ", "_").Replace(name)
+}
+
+// escapeTitle prefixes the words of a title that the importer would read as
+// markers with a backslash. isMarker reports such words, first is set for the
+// first word.
+func escapeTitle(title string, isMarker func(word string, first bool) bool) string {
+	words := strings.Fields(title)
+	for i, word := range words {
+		if strings.HasPrefix(word, `\`) || isMarker(word, i == 0) {
+			words[i] = `\` + word
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// taskLocation returns the time zone the task was created in, so that
+// all-day dates stored as UTC instants map back to the right calendar day
+func taskLocation(task models.Task) *time.Location {
+	if task.TimeZone != "" {
+		if loc, err := time.LoadLocation(task.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// taskDate formats one of the task's dates as a plain date
+func taskDate(task models.Task, t *models.TickTickTime) string {
+	return t.Time.In(taskLocation(task)).Format("2006-01-02")
+}
+
+// parseRepeatFlag extracts the frequency and interval from a TickTick
+// repeat flag such as "RRULE:FREQ=WEEKLY;INTERVAL=2"
+func parseRepeatFlag(flag string) (freq string, interval int, ok bool) {
+	rule, found := strings.CutPrefix(flag, "RRULE:")
+	if !found {
+		return "", 0, false
+	}
+
+	interval = 1
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			freq = value
+		case "INTERVAL":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				interval = n
+			}
+		}
+	}
+
+	return freq, interval, freq != ""
+}
@@ -0,0 +1,227 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"ticktick-tui/internal/importer"
+	"ticktick-tui/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+func date(s string) *models.TickTickTime {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return &models.TickTickTime{Time: t}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := []models.ProjectData{
+		{
+			Project: models.Project{ID: "p1", Name: "Home Office", SortOrder: 1},
+			Tasks: []models.Task{
+				{ID: "t1", Title: "Buy milk", Priority: models.PriorityHigh, Tags: []string{"errands", "to_read", "big deal"}, SortOrder: 1},
+				{ID: "t2", Title: "Fix #42 for +Work @home", DueDate: date("2024-03-01"), IsAllDay: true, SortOrder: 2},
+				{ID: "t3", Title: "x marks the spot", Status: models.StatusCompleted, Priority: models.PriorityLow, SortOrder: 3},
+				{ID: "t4", Title: `2024-01-01 due:soon pri:A \path 📅 ⏫`, StartDate: date("2024-02-01"), IsAllDay: true, SortOrder: 4},
+				{ID: "t5", Title: "(B) not a priority", SortOrder: 5},
+			},
+		},
+		{
+			Project: models.Project{ID: models.InboxID},
+			Tasks: []models.Task{
+				{ID: "t6", Title: "Inbox task", Tags: []string{`back\slash`}},
+			},
+		},
+	}
+
+	// The Inbox comes first and has no project
+	want := []importer.Entry{{Task: data[1].Tasks[0]}}
+	for _, task := range data[0].Tasks {
+		want = append(want, importer.Entry{Project: data[0].Project.Name, Task: task})
+	}
+
+	tests := []struct {
+		export Format
+		parse  importer.Format
+	}{
+		{FormatTodoTxt, importer.FormatTodoTxt},
+		{FormatMarkdown, importer.FormatMarkdown},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, tt.export, data); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := importer.Parse(tt.parse, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(want) {
+			t.Fatalf("%s: got %d entries, want %d:\n%s", tt.export, len(entries), len(want), buf.String())
+		}
+
+		for i, got := range entries {
+			w := want[i]
+			if got.Project != w.Project {
+				t.Errorf("%s: task %q: project = %q, want %q", tt.export, w.Task.Title, got.Project, w.Project)
+			}
+			if got.Task.Title != w.Task.Title {
+				t.Errorf("%s: title = %q, want %q", tt.export, got.Task.Title, w.Task.Title)
+			}
+			if !reflect.DeepEqual(got.Task.Tags, w.Task.Tags) {
+				t.Errorf("%s: task %q: tags = %q, want %q", tt.export, w.Task.Title, got.Task.Tags, w.Task.Tags)
+			}
+			if got.Task.Priority != w.Task.Priority {
+				t.Errorf("%s: task %q: priority = %v, want %v", tt.export, w.Task.Title, got.Task.Priority, w.Task.Priority)
+			}
+			if got.Task.Status != w.Task.Status {
+				t.Errorf("%s: task %q: status = %d, want %d", tt.export, w.Task.Title, got.Task.Status, w.Task.Status)
+			}
+			if !sameDate(got.Task.DueDate, w.Task.DueDate) || !sameDate(got.Task.StartDate, w.Task.StartDate) {
+				t.Errorf("%s: task %q: dates = %v %v, want %v %v", tt.export, w.Task.Title,
+					got.Task.StartDate, got.Task.DueDate, w.Task.StartDate, w.Task.DueDate)
+			}
+		}
+	}
+}
+
+func sameDate(a, b *models.TickTickTime) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Time.Equal(b.Time)
+}
+
+func TestMarkdownNotesRoundTrip(t *testing.T) {
+	notes := "# not a heading\n- [ ] not an item\n\\leading backslash\nplain"
+	data := []models.ProjectData{{
+		Project: models.Project{Name: "Notes"},
+		Tasks: []models.Task{{
+			Title:   "Task",
+			Content: notes,
+			Items:   []models.ChecklistItem{{Title: "Item", Status: models.ItemStatusCompleted}},
+		}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMarkdown, data); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := importer.Parse(importer.FormatMarkdown, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	task := entries[0].Task
+	if task.Content != notes {
+		t.Errorf("content = %q, want %q", task.Content, notes)
+	}
+	if len(task.Items) != 1 || task.Items[0].Title != "Item" || task.Items[0].Status != models.ItemStatusCompleted {
+		t.Errorf("items = %+v", task.Items)
+	}
+}
+
+func TestEscapeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"plain", "plain"},
+		{"two words", "two_words"},
+		{"snake_case", `snake\_case`},
+		{`back\slash`, `back\\slash`},
+		{"mixed _ case", `mixed_\__case`},
+	}
+	for _, tt := range tests {
+		if got := escapeName(tt.name); got != tt.want {
+			t.Errorf("escapeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTodoTxtLine(t *testing.T) {
+	project := models.Project{Name: "Work"}
+	tests := []struct {
+		task models.Task
+		want string
+	}{
+		{models.Task{Title: "Plain"}, "Plain +Work"},
+		{models.Task{Title: "Urgent", Priority: models.PriorityHigh}, "(A) Urgent +Work"},
+		{
+			models.Task{Title: "Done", Status: models.StatusCompleted, Priority: models.PriorityMedium, CompletedTime: date("2024-01-02")},
+			"x 2024-01-02 Done +Work pri:B",
+		},
+		{models.Task{Title: "Tagged", Tags: []string{"a b"}, DueDate: date("2024-01-10")}, "Tagged +Work @a_b due:2024-01-10"},
+		{models.Task{Title: "x +y @z"}, `\x \+y \@z +Work`},
+	}
+	for _, tt := range tests {
+		if got := todoTxtLine(project, tt.task); got != tt.want {
+			t.Errorf("todoTxtLine(%q) = %q, want %q", tt.task.Title, got, tt.want)
+		}
+	}
+}
+
+func TestICSEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a;b,c", `a\;b\,c`},
+		{`back\slash`, `back\\slash`},
+		{"two\nlines\r\nend", `two\nlines\nend`},
+	}
+	for _, tt := range tests {
+		if got := icsEscape(tt.in); got != tt.want {
+			t.Errorf("icsEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestICSLineFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:short"},
+		{"exact", "SUMMARY:" + strings.Repeat("a", icsLineLimit-len("SUMMARY:"))},
+		{"ascii", "SUMMARY:" + strings.Repeat("a", 200)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("任务", 60)},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		iw := &icsWriter{w: bufio.NewWriter(&buf)}
+		iw.line(tt.line)
+		iw.w.Flush()
+
+		out := buf.String()
+		if !strings.HasSuffix(out, "\r\n") {
+			t.Errorf("%s: line not terminated with CRLF", tt.name)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+		for i, l := range lines {
+			if len(l) > icsLineLimit {
+				t.Errorf("%s: line %d is %d octets long", tt.name, i, len(l))
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("%s: line %d splits a character", tt.name, i)
+			}
+			if i > 0 && !strings.HasPrefix(l, " ") {
+				t.Errorf("%s: continuation line %d does not start with a space", tt.name, i)
+			}
+		}
+
+		// Unfolding restores the original line
+		if got := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); got != tt.line {
+			t.Errorf("%s: unfolded line = %q, want %q", tt.name, got, tt.line)
+		}
+	}
+}
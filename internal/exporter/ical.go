@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsDateFormat     = "20060102"
+	icsDateTimeFormat = "20060102T150405Z"
+	icsLineLimit      = 75
+	icsUIDDomain      = "@ticktick.com"
+)
+
+// iCalendar priorities: 1 is the highest, 9 the lowest
+var icsPriorities = map[models.TaskPriority]string{
+	models.PriorityHigh:   "1",
+	models.PriorityMedium: "5",
+	models.PriorityLow:    "9",
+}
+
+// WriteICS writes the tasks as an iCalendar VTODO collection named name.
+// Reminders become VALARMs, repeat flags become RRULEs and checklist items
+// become VTODOs related to their task.
+func WriteICS(w io.Writer, name string, data []models.ProjectData) error {
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//ticktick-tui//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	iw.line("X-WR-CALNAME:" + icsEscape(name))
+
+	for _, project := range data {
+		for _, task := range project.Tasks {
+			writeVTodo(iw, project.Project, task)
+		}
+	}
+
+	iw.line("END:VCALENDAR")
+	return iw.w.Flush()
+}
+
+func writeVTodo(iw *icsWriter, project models.Project, task models.Task) {
+	uid := task.ID + icsUIDDomain
+
+	iw.line("BEGIN:VTODO")
+	iw.line("UID:" + uid)
+	iw.line("DTSTAMP:" + icsStamp(task))
+	iw.line("SUMMARY:" + icsEscape(task.Title))
+	if notes := taskNotes(task); notes != "" {
+		iw.line("DESCRIPTION:" + icsEscape(notes))
+	}
+
+	categories := []string{}
+	if project.Name != "" {
+		categories = append(categories, icsEscape(project.Name))
+	}
+	for _, tag := range task.Tags {
+		categories = append(categories, icsEscape(tag))
+	}
+	if len(categories) > 0 {
+		iw.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+
+	if task.StartDate != nil {
+		iw.line(icsDateProperty("DTSTART", task, task.StartDate))
+	}
+	if task.DueDate != nil {
+		iw.line(icsDateProperty("DUE", task, task.DueDate))
+	}
+	if priority, ok := icsPriorities[task.Priority]; ok {
+		iw.line("PRIORITY:" + priority)
+	}
+	if rule, ok := strings.CutPrefix(task.RepeatFlag, "RRULE:"); ok && rule != "" {
+		iw.line("RRULE:" + rule)
+	}
+
+	if task.Status == models.StatusCompleted {
+		iw.line("STATUS:COMPLETED")
+		iw.line("PERCENT-COMPLETE:100")
+		if task.CompletedTime != nil {
+			iw.line("COMPLETED:" + task.CompletedTime.Time.UTC().Format(icsDateTimeFormat))
+		}
+	} else {
+		iw.line("STATUS:NEEDS-ACTION")
+	}
+
+	for _, reminder := range task.Reminders {
+		trigger := strings.TrimPrefix(reminder, "TRIGGER:")
+		if trigger == "" {
+			continue
+		}
+		iw.line("BEGIN:VALARM")
+		iw.line("ACTION:DISPLAY")
+		iw.line("DESCRIPTION:" + icsEscape(task.Title))
+		// TickTick reminders are relative to the due date
+		if task.DueDate != nil {
+			iw.line("TRIGGER;RELATED=END:" + trigger)
+		} else {
+			iw.line("TRIGGER:" + trigger)
+		}
+		iw.line("END:VALARM")
+	}
+
+	iw.line("END:VTODO")
+
+	for _, item := range task.Items {
+		iw.line("BEGIN:VTODO")
+		iw.line("UID:" + item.ID + icsUIDDomain)
+		iw.line("DTSTAMP:" + icsStamp(task))
+		iw.line("RELATED-TO:" + uid)
+		iw.line("SUMMARY:" + icsEscape(item.Title))
+		if item.Status == models.ItemStatusCompleted {
+			iw.line("STATUS:COMPLETED")
+		} else {
+			iw.line("STATUS:NEEDS-ACTION")
+		}
+		iw.line("END:VTODO")
+	}
+}
+
+// icsDateProperty renders all-day dates as DATE values and everything else
+// as UTC date-times
+func icsDateProperty(name string, task models.Task, t *models.TickTickTime) string {
+	if task.IsAllDay {
+		return name + ";VALUE=DATE:" + t.Time.In(taskLocation(task)).Format(icsDateFormat)
+	}
+	return name + ":" + t.Time.UTC().Format(icsDateTimeFormat)
+}
+
+// icsStamp derives DTSTAMP from the task itself instead of the export time,
+// so that unchanged tasks always render identically
+func icsStamp(task models.Task) string {
+	stamp := time.Unix(0, 0)
+	for _, t := range []*models.TickTickTime{task.CompletedTime, task.DueDate, task.StartDate} {
+		if t != nil && !t.Time.IsZero() {
+			stamp = t.Time
+			break
+		}
+	}
+	return stamp.UTC().Format(icsDateTimeFormat)
+}
+
+// icsEscape escapes TEXT values as described in RFC 5545 section 3.3.11
+func icsEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+type icsWriter struct {
+	w *bufio.Writer
+}
+
+// line writes a content line, folding it at 75 octets without splitting
+// multi-byte characters
+func (iw *icsWriter) line(s string) {
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		iw.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space
+		limit = icsLineLimit - 1
+	}
+	iw.w.WriteString(s + "\r\n")
+}
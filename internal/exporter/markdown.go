@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+)
+
+// Inline markers follow the Obsidian Tasks conventions
+var markdownPriorities = map[models.TaskPriority]string{
+	models.PriorityHigh:   "⏫",
+	models.PriorityMedium: "🔼",
+	models.PriorityLow:    "🔽",
+}
+
+// writeMarkdown writes one heading per project followed by a checklist
+func writeMarkdown(w io.Writer, data []models.ProjectData) error {
+	bw := bufio.NewWriter(w)
+
+	for i, project := range data {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		// Inbox tasks come first, before any heading
+		if !isInbox(project.Project) {
+			bw.WriteString("# " + project.Project.Name + "\n\n")
+		}
+
+		for _, task := range project.Tasks {
+			bw.WriteString("- " + markdownCheckbox(task.Status == models.StatusCompleted) + " " + markdownTaskLine(task) + "\n")
+
+			for _, line := range strings.Split(taskNotes(task), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					bw.WriteString("  " + markdownNote(line) + "\n")
+				}
+			}
+			for _, item := range task.Items {
+				bw.WriteString("  - " + markdownCheckbox(item.Status == models.ItemStatusCompleted) + " " + item.Title + "\n")
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func markdownCheckbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func markdownTaskLine(task models.Task) string {
+	parts := []string{escapeTitle(task.Title, markdownMarker)}
+	if marker, ok := markdownPriorities[task.Priority]; ok {
+		parts = append(parts, marker)
+	}
+	if task.StartDate != nil {
+		parts = append(parts, "🛫 "+taskDate(task, task.StartDate))
+	}
+	if task.DueDate != nil {
+		parts = append(parts, "📅 "+taskDate(task, task.DueDate))
+	}
+	for _, tag := range task.Tags {
+		parts = append(parts, "#"+escapeName(tag))
+	}
+	return strings.Join(parts, " ")
+}
+
+// markdownMarker reports title words that the importer would read as a tag,
+// priority or date marker
+func markdownMarker(word string, _ bool) bool {
+	if len(word) > 1 && word[0] == '#' {
+		return true
+	}
+	for _, marker := range markdownPriorities {
+		if word == marker {
+			return true
+		}
+	}
+	return word == "📅" || word == "🛫"
+}
+
+// markdownNote escapes a line of notes that the importer would read as a
+// heading or checklist item
+func markdownNote(line string) string {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, `\`) ||
+		(len(line) > 2 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' && line[2] == '[') {
+		return `\` + line
+	}
+	return line
+}
+
+// taskNotes joins the content and description of a task
+func taskNotes(task models.Task) string {
+	var notes []string
+	if task.Content != "" {
+		notes = append(notes, task.Content)
+	}
+	if task.Desc != "" {
+		notes = append(notes, task.Desc)
+	}
+	return strings.Join(notes, "\n")
+}
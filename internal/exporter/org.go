@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+)
+
+var orgPriorities = map[models.TaskPriority]string{
+	models.PriorityHigh:   "[#A] ",
+	models.PriorityMedium: "[#B] ",
+	models.PriorityLow:    "[#C] ",
+}
+
+var orgRepeaters = map[string]string{
+	"DAILY":   "d",
+	"WEEKLY":  "w",
+	"MONTHLY": "m",
+	"YEARLY":  "y",
+}
+
+// writeOrg writes one top-level heading per project, tasks as TODO entries
+// and checklist items as sub-entries
+func writeOrg(w io.Writer, data []models.ProjectData) error {
+	bw := bufio.NewWriter(w)
+
+	for _, project := range data {
+		bw.WriteString("* " + project.Project.Name + "\n")
+		if project.Project.ID != "" {
+			writeOrgProperties(bw, "  ", project.Project.ID)
+		}
+
+		for _, task := range project.Tasks {
+			keyword := "TODO"
+			if task.Status == models.StatusCompleted {
+				keyword = "DONE"
+			}
+			heading := "** " + keyword + " " + orgPriorities[task.Priority] + task.Title
+			if len(task.Tags) > 0 {
+				tags := make([]string, len(task.Tags))
+				for i, tag := range task.Tags {
+					tags[i] = strings.ReplaceAll(tag, " ", "_")
+				}
+				heading += " :" + strings.Join(tags, ":") + ":"
+			}
+			bw.WriteString(heading + "\n")
+
+			if planning := orgPlanning(task); planning != "" {
+				bw.WriteString("   " + planning + "\n")
+			}
+			if task.ID != "" {
+				writeOrgProperties(bw, "   ", task.ID)
+			}
+			for _, line := range strings.Split(taskNotes(task), "\n") {
+				if strings.TrimSpace(line) != "" {
+					bw.WriteString("   " + line + "\n")
+				}
+			}
+
+			for _, item := range task.Items {
+				keyword := "TODO"
+				if item.Status == models.ItemStatusCompleted {
+					keyword = "DONE"
+				}
+				bw.WriteString("*** " + keyword + " " + item.Title + "\n")
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeOrgProperties(bw *bufio.Writer, indent, id string) {
+	bw.WriteString(indent + ":PROPERTIES:\n")
+	bw.WriteString(indent + ":ID: " + id + "\n")
+	bw.WriteString(indent + ":END:\n")
+}
+
+// orgPlanning builds the CLOSED/SCHEDULED/DEADLINE line of a task
+func orgPlanning(task models.Task) string {
+	var repeater string
+	if freq, interval, ok := parseRepeatFlag(task.RepeatFlag); ok {
+		if unit, ok := orgRepeaters[freq]; ok {
+			repeater = fmt.Sprintf(" +%d%s", interval, unit)
+		}
+	}
+
+	var parts []string
+	if task.Status == models.StatusCompleted && task.CompletedTime != nil {
+		parts = append(parts, "CLOSED: ["+orgTimestamp(task, task.CompletedTime, false)+"]")
+	}
+	if task.StartDate != nil {
+		parts = append(parts, "SCHEDULED: <"+orgTimestamp(task, task.StartDate, task.IsAllDay)+repeater+">")
+	}
+	if task.DueDate != nil {
+		parts = append(parts, "DEADLINE: <"+orgTimestamp(task, task.DueDate, task.IsAllDay)+repeater+">")
+	}
+	return strings.Join(parts, " ")
+}
+
+func orgTimestamp(task models.Task, date *models.TickTickTime, allDay bool) string {
+	t := date.Time.In(taskLocation(task))
+	if allDay {
+		return t.Format("2006-01-02 Mon")
+	}
+	return t.Format("2006-01-02 Mon 15:04")
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+var todoTxtPriorities = map[models.TaskPriority]string{
+	models.PriorityHigh:   "A",
+	models.PriorityMedium: "B",
+	models.PriorityLow:    "C",
+}
+
+// writeTodoTxt writes one line per task. todo.txt has no nesting, so
+// checklist items and content are not exported.
+func writeTodoTxt(w io.Writer, data []models.ProjectData) error {
+	bw := bufio.NewWriter(w)
+
+	for _, project := range data {
+		for _, task := range project.Tasks {
+			bw.WriteString(todoTxtLine(project.Project, task))
+			bw.WriteString("\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+func todoTxtLine(project models.Project, task models.Task) string {
+	var parts []string
+
+	priority, hasPriority := todoTxtPriorities[task.Priority]
+	if task.Status == models.StatusCompleted {
+		parts = append(parts, "x")
+		if task.CompletedTime != nil {
+			parts = append(parts, taskDate(task, task.CompletedTime))
+		}
+	} else if hasPriority {
+		parts = append(parts, "("+priority+")")
+	}
+
+	parts = append(parts, escapeTitle(task.Title, todoTxtMarker))
+
+	if !isInbox(project) {
+		parts = append(parts, "+"+escapeName(project.Name))
+	}
+	for _, tag := range task.Tags {
+		parts = append(parts, "@"+escapeName(tag))
+	}
+	if task.StartDate != nil {
+		parts = append(parts, "t:"+taskDate(task, task.StartDate))
+	}
+	if task.DueDate != nil {
+		parts = append(parts, "due:"+taskDate(task, task.DueDate))
+	}
+	// Completed tasks keep their priority as a pri: tag
+	if task.Status == models.StatusCompleted && hasPriority {
+		parts = append(parts, "pri:"+priority)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// todoTxtMarker reports title words that the importer would read as a
+// project, context, key:value tag, or as the completion marker, priority or
+// a date at the start of the line
+func todoTxtMarker(word string, first bool) bool {
+	if len(word) > 1 && (word[0] == '+' || word[0] == '@') {
+		return true
+	}
+	for _, prefix := range []string{"due:", "t:", "pri:"} {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	if !first {
+		return false
+	}
+	if word == "x" || (len(word) == 3 && word[0] == '(' && word[2] == ')') {
+		return true
+	}
+	_, err := time.Parse("2006-01-02", word)
+	return err == nil
+}
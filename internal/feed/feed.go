@@ -0,0 +1,263 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/exporter"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// AllFeed is the name of the feed that combines every project
+const AllFeed = "all"
+
+type feedEntry struct {
+	data []byte
+	etag string
+}
+
+func newFeedEntry(data []byte) *feedEntry {
+	sum := sha256.Sum256(data)
+	return &feedEntry{
+		data: data,
+		etag: `"` + hex.EncodeToString(sum[:8]) + `"`,
+	}
+}
+
+// Server publishes the dated tasks of every project as iCalendar feeds.
+// Project feeds are named by project ID rather than by name, so that their
+// URLs keep working when a project is renamed. IDs are not secret, access is
+// guarded by the token. Feeds are rebuilt periodically and served from
+// memory; the last good version is also written to the cache directory so a
+// restarted server can answer before its first refresh completes.
+type Server struct {
+	client   *client.Client
+	token    string
+	interval time.Duration
+	cacheDir string
+
+	mu      sync.RWMutex
+	feeds   map[string]*feedEntry
+	updated time.Time
+}
+
+// NewServer creates a feed server. Requests must carry token as the first
+// path segment. cacheDir may be empty to disable the on-disk cache.
+func NewServer(c *client.Client, token string, interval time.Duration, cacheDir string) *Server {
+	s := &Server{
+		client:   c,
+		token:    token,
+		interval: interval,
+		cacheDir: cacheDir,
+		feeds:    make(map[string]*feedEntry),
+	}
+	s.loadCache()
+	return s
+}
+
+// Refresh fetches all projects and rebuilds every feed
+func (s *Server) Refresh() error {
+	projects, err := s.client.GetProjects()
+	if err != nil {
+		return fmt.Errorf("getting projects: %w", err)
+	}
+
+	var all []models.ProjectData
+	feeds := make(map[string]*feedEntry, len(projects)+1)
+	for _, project := range projects {
+		if project.Closed {
+			continue
+		}
+		data, err := s.client.GetProjectData(project.ID)
+		if err != nil {
+			return fmt.Errorf("getting project %s: %w", project.Name, err)
+		}
+		data.Tasks = datedTasks(data.Tasks)
+		all = append(all, *data)
+
+		entry, err := buildFeed(project.ID, project.Name, []models.ProjectData{*data})
+		if err != nil {
+			return err
+		}
+		feeds[project.ID] = entry
+	}
+
+	entry, err := buildFeed(AllFeed, "TickTick", all)
+	if err != nil {
+		return err
+	}
+	feeds[AllFeed] = entry
+
+	s.mu.Lock()
+	s.feeds = feeds
+	s.updated = time.Now()
+	s.mu.Unlock()
+
+	s.saveCache(feeds)
+	return nil
+}
+
+// Run serves the feeds on addr and refreshes them every interval until ctx
+// is cancelled
+func (s *Server) Run(ctx context.Context, addr string) error {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(); err != nil {
+					slog.Warn("feed refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Feeds returns the names of all available feeds, the combined feed first
+func (s *Server) Feeds() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.feeds))
+	for name := range s.feeds {
+		if name != AllFeed {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{AllFeed}, names...)
+}
+
+// ServeHTTP serves /<token>/<feed>.ics
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, file, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	name, ok := strings.CutSuffix(file, ".ics")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	entry := s.feeds[name]
+	updated := s.updated
+	s.mu.RUnlock()
+
+	if entry == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(s.interval.Seconds())))
+	w.Header().Set("ETag", entry.etag)
+	http.ServeContent(w, r, name+".ics", updated, bytes.NewReader(entry.data))
+}
+
+// datedTasks keeps the tasks that can be placed on a calendar
+func datedTasks(tasks []models.Task) []models.Task {
+	var dated []models.Task
+	for _, task := range tasks {
+		if task.StartDate != nil || task.DueDate != nil {
+			dated = append(dated, task)
+		}
+	}
+	return dated
+}
+
+func buildFeed(name, title string, data []models.ProjectData) (*feedEntry, error) {
+	var buf bytes.Buffer
+	if err := exporter.WriteICS(&buf, title, data); err != nil {
+		return nil, fmt.Errorf("building feed %s: %w", name, err)
+	}
+	return newFeedEntry(buf.Bytes()), nil
+}
+
+func (s *Server) loadCache() {
+	if s.cacheDir == "" {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(s.cacheDir, "*.ics"))
+	if err != nil {
+		return
+	}
+
+	var latest time.Time
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		s.feeds[strings.TrimSuffix(filepath.Base(file), ".ics")] = newFeedEntry(data)
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	s.updated = latest
+}
+
+func (s *Server) saveCache(feeds map[string]*feedEntry) {
+	if s.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(s.cacheDir, 0o700); err != nil {
+		slog.Warn("writing feed cache failed", "error", err)
+		return
+	}
+
+	// Drop feeds of projects that no longer exist
+	if files, err := filepath.Glob(filepath.Join(s.cacheDir, "*.ics")); err == nil {
+		for _, file := range files {
+			if _, ok := feeds[strings.TrimSuffix(filepath.Base(file), ".ics")]; !ok {
+				os.Remove(file)
+			}
+		}
+	}
+
+	for name, entry := range feeds {
+		if err := os.WriteFile(filepath.Join(s.cacheDir, name+".ics"), entry.data, 0o600); err != nil {
+			slog.Warn("writing feed cache failed", "feed", name, "error", err)
+		}
+	}
+}
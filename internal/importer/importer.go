@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// Format identifies a supported import format
+type Format string
+
+const (
+	FormatTodoTxt     Format = "todotxt"
+	FormatMarkdown    Format = "markdown"
+	FormatTodoistCSV  Format = "todoist-csv"
+	FormatTaskwarrior Format = "taskwarrior"
+)
+
+// Formats lists all supported import formats
+var Formats = []Format{
+	FormatTodoTxt,
+	FormatMarkdown,
+	FormatTodoistCSV,
+	FormatTaskwarrior,
+}
+
+// Entry is a parsed task together with the name of the project it belongs to.
+// An empty Project means the source did not specify one.
+type Entry struct {
+	Project string
+	Task    models.Task
+}
+
+// Parse reads all tasks from r in the given format
+func Parse(format Format, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatTodoTxt:
+		return parseTodoTxt(r)
+	case FormatMarkdown:
+		return parseMarkdown(r)
+	case FormatTodoistCSV:
+		return parseTodoistCSV(r)
+	case FormatTaskwarrior:
+		return parseTaskwarrior(r)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// parseDate parses a plain YYYY-MM-DD date as a local all-day date
+func parseDate(s string) (*models.TickTickTime, bool) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &models.TickTickTime{Time: t}, true
+}
+
+// addTag appends tag to the task unless it is already present
+func addTag(task *models.Task, tag string) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return
+	}
+	for _, t := range task.Tags {
+		if strings.EqualFold(t, tag) {
+			return
+		}
+	}
+	task.Tags = append(task.Tags, tag)
+}
+
+// unescapeName reverses how the exporter writes project and tag names as one
+// word: underscores stand for spaces and a backslash keeps the next character
+// as it is
+func unescapeName(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == '_':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapedWord returns a title word that the exporter escaped with a
+// backslash because it would otherwise be read as a marker
+func escapedWord(field string) (string, bool) {
+	if len(field) > 1 && field[0] == '\\' {
+		return field[1:], true
+	}
+	return "", false
+}
+
+// appendContent adds a line to the task content
+func appendContent(task *models.Task, line string) {
+	if task.Content != "" {
+		task.Content += "\n"
+	}
+	task.Content += line
+}
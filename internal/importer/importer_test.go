@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+func localDate(s string) *models.TickTickTime {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return &models.TickTickTime{Time: t}
+}
+
+func utcTime(s string) *models.TickTickTime {
+	t, err := time.Parse(taskwarriorTimeFormat, s)
+	if err != nil {
+		panic(err)
+	}
+	return &models.TickTickTime{Time: t}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   []Entry
+	}{
+		{
+			name:   "todo.txt",
+			format: FormatTodoTxt,
+			input: `(A) 2024-01-01 Call mom +Family_Stuff @phone due:2024-01-10
+x 2024-01-02 Pay rent t:2024-01-01 pri:B
+
+Plain task +First +Second @a_b @A_B due:later
+\+not \@tags \due:2024-01-01 @back\\slash @snake\_case`,
+			want: []Entry{
+				{Project: "Family Stuff", Task: models.Task{
+					Title: "Call mom", Priority: models.PriorityHigh, Tags: []string{"phone"},
+					DueDate: localDate("2024-01-10"), IsAllDay: true,
+				}},
+				{Task: models.Task{
+					Title: "Pay rent", Status: models.StatusCompleted, CompletedTime: localDate("2024-01-02"),
+					Priority: models.PriorityMedium, StartDate: localDate("2024-01-01"), IsAllDay: true,
+				}},
+				{Project: "First", Task: models.Task{Title: "Plain task +Second due:later", Tags: []string{"a b"}}},
+				{Task: models.Task{Title: "+not @tags due:2024-01-01", Tags: []string{`back\slash`, "snake_case"}}},
+			},
+		},
+		{
+			name:   "markdown",
+			format: FormatMarkdown,
+			input: `- [ ] Inbox task
+
+# Work
+
+- [x] Ship release ⏫ 📅 2024-02-01 #release
+  Release notes
+  - [x] Tag
+  - [ ] Announce
+  \# not a heading
+* [ ] Review \#42 🛫 2024-01-15 #code_review
+
+## Home
+- [ ] Water plants 🔽
+not indented, ignored`,
+			want: []Entry{
+				{Task: models.Task{Title: "Inbox task"}},
+				{Project: "Work", Task: models.Task{
+					Title: "Ship release", Status: models.StatusCompleted, Priority: models.PriorityHigh,
+					DueDate: localDate("2024-02-01"), IsAllDay: true, Tags: []string{"release"},
+					Content: "Release notes\n# not a heading",
+					Items: []models.ChecklistItem{
+						{Title: "Tag", Status: models.ItemStatusCompleted},
+						{Title: "Announce"},
+					},
+				}},
+				{Project: "Work", Task: models.Task{
+					Title: "Review #42", StartDate: localDate("2024-01-15"), IsAllDay: true, Tags: []string{"code review"},
+				}},
+				{Project: "Home", Task: models.Task{Title: "Water plants", Priority: models.PriorityLow}},
+			},
+		},
+		{
+			name:   "todoist csv",
+			format: FormatTodoistCSV,
+			input: "\ufeffTYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,DATE\n" +
+				"task,Write report @work,Quarterly,1,1,2024-03-01\n" +
+				"note,Ask Bob,,,,\n" +
+				"task,Outline,,4,2,\n" +
+				"section,Later,,,,\n" +
+				"task,Read book,,4,1,every monday\n",
+			want: []Entry{
+				{Task: models.Task{
+					Title: "Write report", Desc: "Quarterly", Priority: models.PriorityHigh, Tags: []string{"work"},
+					DueDate: localDate("2024-03-01"), IsAllDay: true, Content: "Ask Bob",
+					Items: []models.ChecklistItem{{Title: "Outline"}},
+				}},
+				{Task: models.Task{Title: "Read book", Tags: []string{"Later"}, Content: "Todoist date: every monday"}},
+			},
+		},
+		{
+			name:   "taskwarrior array",
+			format: FormatTaskwarrior,
+			input: `[
+{"description":"Fix bug","project":"dev","status":"pending","priority":"H","due":"20240105T120000Z","tags":["code","Code"],"recur":"weekly","annotations":[{"description":"see log"}]},
+{"description":"Gone","status":"deleted"},
+{"description":"Template","status":"recurring"},
+{"description":"Done","status":"completed","end":"20240102T080000Z"}
+]`,
+			want: []Entry{
+				{Project: "dev", Task: models.Task{
+					Title: "Fix bug", Priority: models.PriorityHigh, DueDate: utcTime("20240105T120000Z"),
+					Tags: []string{"code"}, RepeatFlag: "RRULE:FREQ=WEEKLY;INTERVAL=1", Content: "see log",
+				}},
+				{Task: models.Task{Title: "Done", Status: models.StatusCompleted, CompletedTime: utcTime("20240102T080000Z")}},
+			},
+		},
+		{
+			name:   "taskwarrior lines",
+			format: FormatTaskwarrior,
+			input:  "{\"description\":\"One\",\"status\":\"pending\",\"priority\":\"L\"},\n{\"description\":\"Two\",\"status\":\"waiting\"}\n",
+			want: []Entry{
+				{Task: models.Task{Title: "One", Priority: models.PriorityLow}},
+				{Task: models.Task{Title: "Two"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("entry %d:\n got %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{"unknown format", Format("csv"), ""},
+		{"todoist without header", FormatTodoistCSV, ""},
+		{"todoist without content column", FormatTodoistCSV, "TYPE,PRIORITY\ntask,1\n"},
+		{"taskwarrior invalid array", FormatTaskwarrior, "[{]"},
+		{"taskwarrior invalid line", FormatTaskwarrior, "{\"description\":"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.format, strings.NewReader(tt.input)); err == nil {
+			t.Errorf("%s: Parse succeeded", tt.name)
+		}
+	}
+}
+
+func TestUnescapeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"two_words", "two words"},
+		{`snake\_case`, "snake_case"},
+		{`back\\slash`, `back\slash`},
+		{`trailing\`, `trailing\`},
+		{"日本_語", "日本 語"},
+	}
+	for _, tt := range tests {
+		if got := unescapeName(tt.in); got != tt.want {
+			t.Errorf("unescapeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+)
+
+// Inline markers follow the Obsidian Tasks conventions
+var markdownPriorities = map[string]models.TaskPriority{
+	"⏫": models.PriorityHigh,
+	"🔼": models.PriorityMedium,
+	"🔽": models.PriorityLow,
+}
+
+const (
+	markdownDueMarker   = "📅"
+	markdownStartMarker = "🛫"
+)
+
+// parseMarkdown reads checklists. Headings set the project of the tasks below
+// them, top-level checkboxes become tasks, nested checkboxes become checklist
+// items and other indented lines are appended to the task content.
+func parseMarkdown(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var project string
+	var current *Entry
+	taskIndent := -1
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		raw := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		line := strings.TrimSpace(raw)
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			if heading != "" {
+				project = heading
+				current = nil
+				taskIndent = -1
+			}
+			continue
+		}
+
+		text, checked, ok := parseCheckbox(line)
+		if ok && (current == nil || indent <= taskIndent) {
+			entries = append(entries, Entry{Project: project})
+			current = &entries[len(entries)-1]
+			taskIndent = indent
+			parseMarkdownTask(&current.Task, text)
+			if checked {
+				current.Task.Status = models.StatusCompleted
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if ok {
+			item := models.ChecklistItem{Title: text}
+			if checked {
+				item.Status = models.ItemStatusCompleted
+			}
+			current.Task.Items = append(current.Task.Items, item)
+		} else if indent > taskIndent {
+			// Escaped lines would have been read as headings or items
+			appendContent(&current.Task, strings.TrimPrefix(line, `\`))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// parseCheckbox recognizes "- [ ] text" and "- [x] text" list items
+func parseCheckbox(line string) (text string, checked bool, ok bool) {
+	if len(line) < 6 || !strings.ContainsRune("-*+", rune(line[0])) || line[1] != ' ' {
+		return "", false, false
+	}
+	box := line[2:5]
+	switch box {
+	case "[ ]":
+	case "[x]", "[X]":
+		checked = true
+	default:
+		return "", false, false
+	}
+	return strings.TrimSpace(line[5:]), checked, true
+}
+
+// parseMarkdownTask extracts inline dates, priority and tags from a task line
+func parseMarkdownTask(task *models.Task, text string) {
+	fields := strings.Fields(text)
+	var title []string
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if word, ok := escapedWord(field); ok {
+			title = append(title, word)
+			continue
+		}
+		if priority, ok := markdownPriorities[field]; ok {
+			task.Priority = priority
+			continue
+		}
+		if (field == markdownDueMarker || field == markdownStartMarker) && i+1 < len(fields) {
+			if date, ok := parseDate(fields[i+1]); ok {
+				if field == markdownDueMarker {
+					task.DueDate = date
+				} else {
+					task.StartDate = date
+				}
+				task.IsAllDay = true
+				i++
+				continue
+			}
+		}
+		if len(field) > 1 && field[0] == '#' {
+			addTag(task, unescapeName(field[1:]))
+			continue
+		}
+		title = append(title, field)
+	}
+
+	task.Title = strings.Join(title, " ")
+}
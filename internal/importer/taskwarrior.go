@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+const taskwarriorTimeFormat = "20060102T150405Z"
+
+var taskwarriorPriorities = map[string]models.TaskPriority{
+	"H": models.PriorityHigh,
+	"M": models.PriorityMedium,
+	"L": models.PriorityLow,
+}
+
+var taskwarriorRecurrences = map[string]string{
+	"daily":    "RRULE:FREQ=DAILY;INTERVAL=1",
+	"weekly":   "RRULE:FREQ=WEEKLY;INTERVAL=1",
+	"biweekly": "RRULE:FREQ=WEEKLY;INTERVAL=2",
+	"monthly":  "RRULE:FREQ=MONTHLY;INTERVAL=1",
+	"yearly":   "RRULE:FREQ=YEARLY;INTERVAL=1",
+	"annual":   "RRULE:FREQ=YEARLY;INTERVAL=1",
+}
+
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Project     string   `json:"project"`
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority"`
+	Due         string   `json:"due"`
+	Scheduled   string   `json:"scheduled"`
+	End         string   `json:"end"`
+	Recur       string   `json:"recur"`
+	Tags        []string `json:"tags"`
+	Annotations []struct {
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+// parseTaskwarrior reads the output of "task export", either as a JSON array
+// or as one JSON object per line. Deleted tasks and recurrence templates are
+// skipped.
+func parseTaskwarrior(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []taskwarriorTask
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &tasks); err != nil {
+			return nil, fmt.Errorf("decoding tasks: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+			if line == "" {
+				continue
+			}
+			var task taskwarriorTask
+			if err := json.Unmarshal([]byte(line), &task); err != nil {
+				return nil, fmt.Errorf("decoding task: %w", err)
+			}
+			tasks = append(tasks, task)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []Entry
+	for _, tw := range tasks {
+		if tw.Status == "deleted" || tw.Status == "recurring" {
+			continue
+		}
+
+		task := models.Task{
+			Title:      tw.Description,
+			Priority:   taskwarriorPriorities[tw.Priority],
+			RepeatFlag: taskwarriorRecurrences[tw.Recur],
+		}
+		if tw.Status == "completed" {
+			task.Status = models.StatusCompleted
+			task.CompletedTime = parseTaskwarriorTime(tw.End)
+		}
+		task.DueDate = parseTaskwarriorTime(tw.Due)
+		task.StartDate = parseTaskwarriorTime(tw.Scheduled)
+		for _, tag := range tw.Tags {
+			addTag(&task, tag)
+		}
+		for _, annotation := range tw.Annotations {
+			appendContent(&task, annotation.Description)
+		}
+
+		entries = append(entries, Entry{Project: tw.Project, Task: task})
+	}
+
+	return entries, nil
+}
+
+func parseTaskwarriorTime(s string) *models.TickTickTime {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(taskwarriorTimeFormat, s)
+	if err != nil {
+		return nil
+	}
+	return &models.TickTickTime{Time: t}
+}
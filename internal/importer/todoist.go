@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// Todoist priorities: 1 is the most important
+var todoistPriorities = map[string]models.TaskPriority{
+	"1": models.PriorityHigh,
+	"2": models.PriorityMedium,
+	"3": models.PriorityLow,
+}
+
+var todoistDateFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"Jan 2 2006",
+	"Jan 2 2006 15:04",
+}
+
+// parseTodoistCSV reads a Todoist project template export. The CSV does not
+// name its project, so entries are returned without one. Sections become
+// tags, notes are appended to the content of the preceding task and tasks
+// with INDENT > 1 become checklist items of their parent.
+func parseTodoistCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"TYPE", "CONTENT"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var entries []Entry
+	var section string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		content := get("CONTENT")
+		switch strings.ToLower(get("TYPE")) {
+		case "section":
+			section = content
+		case "note":
+			if len(entries) > 0 && content != "" {
+				appendContent(&entries[len(entries)-1].Task, content)
+			}
+		case "task":
+			indent, _ := strconv.Atoi(get("INDENT"))
+			if indent > 1 && len(entries) > 0 {
+				parent := &entries[len(entries)-1].Task
+				parent.Items = append(parent.Items, models.ChecklistItem{Title: content})
+				continue
+			}
+
+			var task models.Task
+			parseTodoistContent(&task, content)
+			task.Desc = get("DESCRIPTION")
+			task.Priority = todoistPriorities[get("PRIORITY")]
+			task.TimeZone = get("TIMEZONE")
+			if section != "" {
+				addTag(&task, section)
+			}
+			if date := get("DATE"); date != "" {
+				parseTodoistDate(&task, date)
+			}
+			entries = append(entries, Entry{Task: task})
+		}
+	}
+
+	return entries, nil
+}
+
+// parseTodoistContent splits "@label" words off the task title
+func parseTodoistContent(task *models.Task, content string) {
+	var title []string
+	for _, field := range strings.Fields(content) {
+		if len(field) > 1 && field[0] == '@' {
+			addTag(task, field[1:])
+			continue
+		}
+		title = append(title, field)
+	}
+	task.Title = strings.Join(title, " ")
+}
+
+// parseTodoistDate understands absolute dates only; natural language
+// recurrences such as "every monday" are kept in the content instead.
+func parseTodoistDate(task *models.Task, date string) {
+	loc := time.Local
+	if task.TimeZone != "" {
+		if l, err := time.LoadLocation(task.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	for _, format := range todoistDateFormats {
+		if t, err := time.ParseInLocation(format, date, loc); err == nil {
+			task.DueDate = &models.TickTickTime{Time: t}
+			task.IsAllDay = !strings.Contains(format, "15:04")
+			return
+		}
+	}
+	appendContent(task, "Todoist date: "+date)
+}
@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+)
+
+// todo.txt priorities: (A) is the most important
+var todoTxtPriorities = map[string]models.TaskPriority{
+	"A": models.PriorityHigh,
+	"B": models.PriorityMedium,
+	"C": models.PriorityLow,
+}
+
+func parseTodoTxt(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entries = append(entries, parseTodoTxtLine(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// parseTodoTxtLine parses a single line such as
+// "x 2024-01-02 (A) 2024-01-01 Title +Project @context due:2024-01-10"
+func parseTodoTxtLine(line string) Entry {
+	var entry Entry
+	task := &entry.Task
+
+	fields := strings.Fields(line)
+
+	// Completion marker and completion date
+	if len(fields) > 0 && fields[0] == "x" {
+		task.Status = models.StatusCompleted
+		fields = fields[1:]
+		if len(fields) > 0 {
+			if completed, ok := parseDate(fields[0]); ok {
+				task.CompletedTime = completed
+				fields = fields[1:]
+			}
+		}
+	}
+
+	// Priority
+	if len(fields) > 0 && len(fields[0]) == 3 && fields[0][0] == '(' && fields[0][2] == ')' {
+		task.Priority = todoTxtPriorities[fields[0][1:2]]
+		fields = fields[1:]
+	}
+
+	// Creation date is not supported by the API
+	if len(fields) > 0 {
+		if _, ok := parseDate(fields[0]); ok {
+			fields = fields[1:]
+		}
+	}
+
+	var title []string
+	for _, field := range fields {
+		if word, ok := escapedWord(field); ok {
+			title = append(title, word)
+			continue
+		}
+
+		switch {
+		case len(field) > 1 && field[0] == '+':
+			if entry.Project == "" {
+				entry.Project = unescapeName(field[1:])
+			} else {
+				title = append(title, field)
+			}
+		case len(field) > 1 && field[0] == '@':
+			addTag(task, unescapeName(field[1:]))
+		case strings.HasPrefix(field, "due:"):
+			if due, ok := parseDate(field[4:]); ok {
+				task.DueDate = due
+				task.IsAllDay = true
+			} else {
+				title = append(title, field)
+			}
+		case strings.HasPrefix(field, "t:"):
+			if start, ok := parseDate(field[2:]); ok {
+				task.StartDate = start
+				task.IsAllDay = true
+			} else {
+				title = append(title, field)
+			}
+		case strings.HasPrefix(field, "pri:"):
+			// Completed tasks keep their priority as a pri: tag
+			task.Priority = todoTxtPriorities[field[4:]]
+		default:
+			title = append(title, field)
+		}
+	}
+	task.Title = strings.Join(title, " ")
+
+	return entry
+}
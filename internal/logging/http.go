@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxBodyLog limits how much of a request or response body is logged
+const maxBodyLog = 2048
+
+// sensitiveHeaders are never logged in full
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are redacted in JSON and form bodies
+var sensitiveFields = []string{
+	"access_token",
+	"refresh_token",
+	"client_secret",
+	"code",
+	"code_verifier",
+	"password",
+}
+
+// Transport traces HTTP requests to the default slog logger: the request
+// line, status and duration at info level, and headers and bodies with
+// credentials redacted at debug level
+type Transport struct {
+	// Base performs the requests; http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	logger := slog.Default()
+	ctx := req.Context()
+	debug := logger.Enabled(ctx, slog.LevelDebug)
+
+	if debug {
+		attrs := []any{"method", req.Method, "url", redactURL(req.URL), "headers", redactHeaders(req.Header)}
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(io.LimitReader(body, maxBodyLog+1))
+				body.Close()
+				attrs = append(attrs, "body", redactBody(data, req.Header.Get("Content-Type")))
+			}
+		}
+		logger.DebugContext(ctx, "http request", attrs...)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "http request failed",
+			"method", req.Method, "endpoint", req.URL.Path, "duration", duration, "error", err)
+		return nil, err
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	logger.Log(ctx, level, "http response",
+		"method", req.Method, "endpoint", req.URL.Path, "status", resp.StatusCode, "duration", duration)
+
+	if debug {
+		logResponseBody(ctx, logger, resp)
+	}
+	return resp, nil
+}
+
+// logResponseBody logs the start of the body and puts it back for the caller
+func logResponseBody(ctx context.Context, logger *slog.Logger, resp *http.Response) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyLog+1))
+	if err != nil {
+		return
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+
+	logger.DebugContext(ctx, "http response body",
+		"headers", redactHeaders(resp.Header),
+		"body", redactBody(data, resp.Header.Get("Content-Type")))
+}
+
+func redactURL(u *url.URL) string {
+	clean := *u
+	query := clean.Query()
+	for key := range query {
+		if slices.Contains(sensitiveFields, key) || key == "state" {
+			query.Set(key, "[redacted]")
+		}
+	}
+	clean.RawQuery = query.Encode()
+	clean.User = nil
+	return clean.String()
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for key, values := range h {
+		value := strings.Join(values, ", ")
+		if slices.Contains(sensitiveHeaders, http.CanonicalHeaderKey(key)) {
+			// Keep the scheme, e.g. "Bearer [redacted]"
+			if scheme, _, ok := strings.Cut(value, " "); ok {
+				value = scheme + " [redacted]"
+			} else {
+				value = "[redacted]"
+			}
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// redactBody replaces credentials in JSON and form encoded bodies and
+// truncates long bodies
+func redactBody(data []byte, contentType string) string {
+	truncated := len(data) > maxBodyLog
+	if truncated {
+		data = data[:maxBodyLog]
+	}
+
+	var body string
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		if values, err := url.ParseQuery(string(data)); err == nil {
+			for key := range values {
+				if slices.Contains(sensitiveFields, key) {
+					values.Set(key, "[redacted]")
+				}
+			}
+			body = values.Encode()
+		}
+	case json.Valid(data):
+		var v any
+		if err := json.Unmarshal(data, &v); err == nil {
+			if redacted, err := json.Marshal(redactJSON(v)); err == nil {
+				body = string(redacted)
+			}
+		}
+	}
+	if body == "" {
+		// Unknown or cut off, don't risk logging credentials
+		if bytes.Contains(data, []byte("token")) || bytes.Contains(data, []byte("secret")) {
+			return "[redacted]"
+		}
+		body = string(data)
+	}
+
+	if truncated {
+		body += "…"
+	}
+	return body
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if slices.Contains(sensitiveFields, key) {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Levels are the accepted values of --log-level
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel converts a level name to a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (one of %s)", name, strings.Join(Levels, ", "))
+	}
+	return level, nil
+}
+
+// Options configure Setup
+type Options struct {
+	// File to log to; logging is disabled when empty
+	File  string
+	Level string
+	// MaxSize in bytes before the file is rotated, MaxBackups rotated files
+	// are kept
+	MaxSize    int64
+	MaxBackups int
+}
+
+// Setup installs the default slog logger. The returned closer releases the
+// log file.
+func Setup(opts Options) (io.Closer, error) {
+	// Never fall back to slog's default of writing to stderr, which would
+	// corrupt the TUI
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	level := slog.LevelInfo
+	if opts.Level != "" {
+		var err error
+		if level, err = ParseLevel(opts.Level); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.File == "" {
+		return io.NopCloser(nil), nil
+	}
+
+	file, err := OpenRotatingFile(opts.File, opts.MaxSize, opts.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})))
+	return file, nil
+}
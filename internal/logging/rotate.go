@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated once it grows past a size
+// limit. Rotated files are kept as path.1 (newest) up to path.N.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens path for appending. maxSize is in bytes; zero or
+// less disables rotation.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups, moves the current file to path.1 and starts a
+// new one; f.mu must be held
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if f.maxBackups <= 0 {
+		os.Remove(f.path)
+	} else {
+		os.Remove(f.backup(f.maxBackups))
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(f.backup(i), f.backup(i+1))
+		}
+		if err := os.Rename(f.path, f.backup(1)); err != nil {
+			return err
+		}
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// Close closes the current file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
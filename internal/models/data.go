@@ -4,6 +4,7 @@ package models
 type Task struct {
 	ID            string          `json:"id,omitempty"`
 	ProjectID     string          `json:"projectId"`
+	ColumnID      string          `json:"columnId,omitempty"`
 	Title         string          `json:"title"`
 	Content       string          `json:"content,omitempty"`
 	Desc          string          `json:"desc,omitempty"`
@@ -18,6 +19,7 @@ type Task struct {
 	CompletedTime *TickTickTime   `json:"completedTime,omitempty"`
 	SortOrder     int64           `json:"sortOrder,omitempty"`
 	Items         []ChecklistItem `json:"items,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
 }
 
 // TaskPriority represents the priority level of a task
@@ -30,6 +32,31 @@ const (
 	PriorityHigh   TaskPriority = 5
 )
 
+func (p TaskPriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "Low"
+	case PriorityMedium:
+		return "Medium"
+	case PriorityHigh:
+		return "High"
+	default:
+		return "None"
+	}
+}
+
+// Task status values
+const (
+	StatusNormal    = 0
+	StatusCompleted = 2
+)
+
+// Checklist item status values
+const (
+	ItemStatusNormal    = 0
+	ItemStatusCompleted = 1
+)
+
 // ChecklistItem represents a subtask
 type ChecklistItem struct {
 	ID            string        `json:"id,omitempty"`
@@ -55,6 +82,9 @@ type Project struct {
 	Kind       string `json:"kind,omitempty"`
 }
 
+// InboxID addresses the Inbox, which is not listed among the projects
+const InboxID = "inbox"
+
 // Column represents a project column
 type Column struct {
 	ID        string `json:"id,omitempty"`
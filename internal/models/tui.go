@@ -14,8 +14,30 @@ const (
 	CreateTaskView
 	CreateProjectView
 	DeleteConfirmView
+	SearchView
+	BoardView
 )
 
+var viewNames = map[ViewState]string{
+	ConfigView:        "config",
+	AuthView:          "auth",
+	ProjectListView:   "projects",
+	TaskListView:      "tasks",
+	TaskDetailView:    "task",
+	CreateTaskView:    "create-task",
+	CreateProjectView: "create-project",
+	DeleteConfirmView: "delete-confirm",
+	SearchView:        "search",
+	BoardView:         "board",
+}
+
+func (v ViewState) String() string {
+	if name, ok := viewNames[v]; ok {
+		return name
+	}
+	return "unknown"
+}
+
 // AppState represents the application state for the TUI
 type AppState struct {
 	CurrentView ViewState
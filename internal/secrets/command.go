@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CommandStore runs a configured shell command to obtain a secret, e.g.
+// "pass show ticktick" for access_token_cmd. Only the first line of the
+// output is used, like password managers print it.
+type CommandStore struct {
+	// Command returns the command configured for key, or "" if there is none
+	Command func(key string) string
+}
+
+func (s CommandStore) Name() string {
+	return "command"
+}
+
+func (s CommandStore) Get(key string) (string, error) {
+	command := s.Command(key)
+	if command == "" {
+		return "", ErrNotFound
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("running %q: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("running %q: %w", command, err)
+	}
+
+	value, _, _ := strings.Cut(string(out), "\n")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("running %q: no output", command)
+	}
+	return value, nil
+}
+
+func (s CommandStore) Set(key, value string) error {
+	return ErrReadOnly
+}
+
+func (s CommandStore) Delete(key string) error {
+	return ErrReadOnly
+}
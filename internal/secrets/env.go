@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvStore reads secrets from environment variables named Prefix followed
+// by the upper-cased key, e.g. TICKTICK_ACCESS_TOKEN
+type EnvStore struct {
+	Prefix string
+}
+
+func (s EnvStore) Name() string {
+	return "env"
+}
+
+// Variable returns the environment variable consulted for key
+func (s EnvStore) Variable(key string) string {
+	return s.Prefix + strings.ToUpper(key)
+}
+
+func (s EnvStore) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(s.Variable(key))
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s EnvStore) Set(key, value string) error {
+	return ErrReadOnly
+}
+
+func (s EnvStore) Delete(key string) error {
+	return ErrReadOnly
+}
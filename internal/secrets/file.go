@@ -0,0 +1,213 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	fileVersion = 1
+
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations = 600000
+	// maxPbkdf2Iterations bounds the count read from a file, which could
+	// otherwise make opening it take forever
+	maxPbkdf2Iterations = 10 * pbkdf2Iterations
+
+	saltSize = 16
+)
+
+// additionalData binds the ciphertext to this file format
+var additionalData = []byte("ticktick-tui secrets v1")
+
+// ErrWrongPassphrase is returned when the secrets file cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted secrets file")
+
+// fileFormat is the on-disk layout of an encrypted secrets file
+type fileFormat struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore keeps secrets in a file encrypted with AES-256-GCM under a key
+// derived from a passphrase with PBKDF2
+type FileStore struct {
+	path       string
+	aead       cipher.AEAD
+	salt       []byte
+	iterations int
+
+	mu   sync.Mutex
+	data map[string]string
+}
+
+// OpenFile decrypts the secrets file at path with passphrase. A missing
+// file is created on the first Set.
+func OpenFile(path string, passphrase []byte) (*FileStore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(passphrase, salt, pbkdf2Iterations)
+		if err != nil {
+			return nil, err
+		}
+		return &FileStore{path: path, aead: aead, salt: salt, iterations: pbkdf2Iterations, data: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if f.Version != fileVersion || f.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported secrets file version %d (%s)", f.Version, f.KDF)
+	}
+	// The parameters are not authenticated, a tampered file must not weaken
+	// the key or stall the derivation
+	if f.Iterations < pbkdf2Iterations || f.Iterations > maxPbkdf2Iterations {
+		return nil, fmt.Errorf("secrets file has an invalid iteration count %d", f.Iterations)
+	}
+	if len(f.Salt) < saltSize {
+		return nil, errors.New("secrets file has an invalid salt")
+	}
+
+	aead, err := newAEAD(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		// GCM panics on nonces of the wrong size
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	data := map[string]string{}
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	return &FileStore{path: path, aead: aead, salt: f.Salt, iterations: f.Iterations, data: data}, nil
+}
+
+func newAEAD(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *FileStore) Name() string {
+	return "file"
+}
+
+// Path returns the location of the secrets file
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+func (s *FileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Keys returns the names of all stored secrets
+func (s *FileStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *FileStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+	return s.save()
+}
+
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[key]; !ok {
+		return nil
+	}
+	delete(s.data, key)
+	return s.save()
+}
+
+// save encrypts the secrets with a fresh nonce and replaces the file
+// atomically
+func (s *FileStore) save() error {
+	plaintext, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(fileFormat{
+		Version:    fileVersion,
+		KDF:        "pbkdf2-sha256",
+		Iterations: s.iterations,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: s.aead.Seal(nil, nonce, plaintext, additionalData),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a store has no value for a key
+	ErrNotFound = errors.New("secret not found")
+
+	// ErrReadOnly is returned when writing to a store that can only be read
+	ErrReadOnly = errors.New("secret store is read-only")
+)
+
+// Store is a place secrets can be looked up in
+type Store interface {
+	// Name identifies the backend in status output
+	Name() string
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// Lookup returns the first value found for key in stores, together with the
+// store it came from
+func Lookup(key string, stores ...Store) (string, Store, error) {
+	for _, store := range stores {
+		value, err := store.Get(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", store, err
+		}
+		return value, store, nil
+	}
+	return "", nil, ErrNotFound
+}
+
+// Redact hides a secret for display. Long values keep a short prefix so
+// different tokens can still be told apart.
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) < 16 {
+		return "********"
+	}
+	return value[:4] + "********"
+}
+
+// RedactAll replaces every occurrence of the given secrets in s
+func RedactAll(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redact(secret))
+		}
+	}
+	return s
+}
@@ -0,0 +1,244 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	passphrase := []byte("correct horse")
+
+	s, err := OpenFile(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"access_token": "access-token-1", "refresh_token": "refresh-token-1", "gone": "x"} {
+		if err := s.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete("gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Base64 has no dashes, so the values can't show up by chance
+	if strings.Contains(string(raw), "-token-") {
+		t.Error("secrets are stored in plain text")
+	}
+
+	s, err = OpenFile(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+		err  error
+	}{
+		{"access_token", "access-token-1", nil},
+		{"refresh_token", "refresh-token-1", nil},
+		{"gone", "", ErrNotFound},
+		{"missing", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := s.Get(tt.key)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Get(%s) = %q, %v, want %q, %v", tt.key, got, err, tt.want, tt.err)
+		}
+	}
+	if keys := s.Keys(); len(keys) != 2 || keys[0] != "access_token" || keys[1] != "refresh_token" {
+		t.Errorf("Keys() = %v", keys)
+	}
+}
+
+func TestOpenFileRejects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	s, err := OpenFile(path, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("access_token", "a1"); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var good fileFormat
+	if err := json.Unmarshal(raw, &good); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		tamper     func(f *fileFormat)
+		wantErr    error
+	}{
+		{"empty passphrase", "", nil, nil},
+		{"wrong passphrase", "guess", nil, ErrWrongPassphrase},
+		{"too few iterations", "secret", func(f *fileFormat) { f.Iterations = 1 }, nil},
+		{"too many iterations", "secret", func(f *fileFormat) { f.Iterations = maxPbkdf2Iterations + 1 }, nil},
+		{"negative iterations", "secret", func(f *fileFormat) { f.Iterations = -1 }, nil},
+		{"short salt", "secret", func(f *fileFormat) { f.Salt = f.Salt[:4] }, nil},
+		{"short nonce", "secret", func(f *fileFormat) { f.Nonce = f.Nonce[:4] }, ErrWrongPassphrase},
+		{"modified ciphertext", "secret", func(f *fileFormat) { f.Ciphertext[0] ^= 1 }, ErrWrongPassphrase},
+		{"unknown version", "secret", func(f *fileFormat) { f.Version = 2 }, nil},
+		{"unknown kdf", "secret", func(f *fileFormat) { f.KDF = "scrypt" }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := good
+			f.Salt = append([]byte(nil), good.Salt...)
+			f.Nonce = append([]byte(nil), good.Nonce...)
+			f.Ciphertext = append([]byte(nil), good.Ciphertext...)
+			if tt.tamper != nil {
+				tt.tamper(&f)
+			}
+			data, err := json.Marshal(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tampered := filepath.Join(t.TempDir(), "secrets.json")
+			if err := os.WriteFile(tampered, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = OpenFile(tampered, []byte(tt.passphrase))
+			if err == nil {
+				t.Fatal("OpenFile succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("OpenFile = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type mapStore struct {
+	name   string
+	values map[string]string
+}
+
+func (m *mapStore) Name() string { return m.name }
+
+func (m *mapStore) Get(key string) (string, error) {
+	if v, ok := m.values[key]; ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+func (m *mapStore) Set(key, value string) error { return ErrReadOnly }
+func (m *mapStore) Delete(key string) error     { return ErrReadOnly }
+
+func TestLookup(t *testing.T) {
+	first := &mapStore{"first", map[string]string{"a": "1"}}
+	second := &mapStore{"second", map[string]string{"a": "2", "b": "3"}}
+	tests := []struct {
+		key       string
+		want      string
+		wantStore Store
+		wantErr   error
+	}{
+		{"a", "1", first, nil},
+		{"b", "3", second, nil},
+		{"c", "", nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, store, err := Lookup(tt.key, first, second)
+		if got != tt.want || store != tt.wantStore || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Lookup(%s) = %q, %v, %v, want %q, %v, %v", tt.key, got, store, err, tt.want, tt.wantStore, tt.wantErr)
+		}
+	}
+}
+
+func TestEnvStore(t *testing.T) {
+	t.Setenv("TEST_SECRETS_ACCESS_TOKEN", "env-token")
+	t.Setenv("TEST_SECRETS_EMPTY", "")
+	s := EnvStore{Prefix: "TEST_SECRETS_"}
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"access_token", "env-token", nil},
+		{"empty", "", ErrNotFound},
+		{"unset", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := s.Get(tt.key)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Get(%s) = %q, %v, want %q, %v", tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if err := s.Set("access_token", "x"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Set = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestCommandStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are run with sh")
+	}
+	commands := map[string]string{
+		"token":  "printf 'first\\nsecond\\n'",
+		"padded": "echo '  spaced  '",
+		"empty":  "true",
+		"fails":  "echo oops >&2; exit 1",
+	}
+	s := CommandStore{Command: func(key string) string { return commands[key] }}
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"token", "first", false},
+		{"padded", "spaced", false},
+		{"empty", "", true},
+		{"fails", "", true},
+		{"none", "", true},
+	}
+	for _, tt := range tests {
+		got, err := s.Get(tt.key)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Get(%s) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+	if _, err := s.Get("none"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get without command = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "********"},
+		{"0123456789abcdef", "0123********"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.value); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	got := RedactAll("token 0123456789abcdef and short", "0123456789abcdef", "short", "")
+	if want := "token 0123******** and ********"; got != want {
+		t.Errorf("RedactAll = %q, want %q", got, want)
+	}
+}
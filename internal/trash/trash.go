@@ -0,0 +1,198 @@
+package trash
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+// Kind identifies what a trash entry holds
+type Kind string
+
+const (
+	KindTask    Kind = "task"
+	KindProject Kind = "project"
+)
+
+// ErrNotFound is returned when no entry matches an ID
+var ErrNotFound = errors.New("trash entry not found")
+
+// Entry is a snapshot of a deleted task, or of a deleted project together
+// with its tasks
+type Entry struct {
+	ID        string              `json:"id"`
+	Kind      Kind                `json:"kind"`
+	DeletedAt time.Time           `json:"deletedAt"`
+	Task      *models.Task        `json:"task,omitempty"`
+	Project   *models.ProjectData `json:"project,omitempty"`
+
+	// What a partly failed restore already created, so that retrying it
+	// doesn't create the project or its tasks twice
+	RestoredProjectID string   `json:"restoredProjectId,omitempty"`
+	RestoredTaskIDs   []string `json:"restoredTaskIds,omitempty"`
+}
+
+// Title returns a short human readable description of the entry
+func (e *Entry) Title() string {
+	switch e.Kind {
+	case KindTask:
+		if e.Task != nil {
+			return e.Task.Title
+		}
+	case KindProject:
+		if e.Project != nil {
+			return e.Project.Project.Name
+		}
+	}
+	return ""
+}
+
+// Store keeps one JSON file per entry in a directory
+type Store struct {
+	dir       string
+	retention time.Duration
+}
+
+// Open opens the trash in dir and drops entries older than retention.
+// A retention of zero or less keeps entries forever.
+func Open(dir string, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, retention: retention}
+	if retention > 0 {
+		if _, err := s.Purge(time.Now().Add(-retention)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ExpiresAt returns when the entry will be dropped automatically
+func (s *Store) ExpiresAt(e *Entry) (time.Time, bool) {
+	if s.retention <= 0 {
+		return time.Time{}, false
+	}
+	return e.DeletedAt.Add(s.retention), true
+}
+
+// Add stores a new entry, assigning its ID and deletion time
+func (s *Store) Add(e *Entry) error {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	e.ID = hex.EncodeToString(buf)
+	e.DeletedAt = time.Now()
+	return s.Update(e)
+}
+
+// Update overwrites a stored entry
+func (s *Store) Update(e *Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(e.ID), data, 0o600)
+}
+
+// List returns all entries, most recently deleted first
+func (s *Store) List() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, file := range files {
+		entry, err := readEntry(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// Get returns the entry with the given ID. Unambiguous ID prefixes are
+// accepted as well.
+func (s *Store) Get(id string) (*Entry, error) {
+	if entry, err := readEntry(s.path(id)); err == nil {
+		return entry, nil
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var match *Entry
+	for i := range entries {
+		if strings.HasPrefix(entries[i].ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("ambiguous trash ID: %s", id)
+			}
+			match = &entries[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrNotFound
+	}
+	return match, nil
+}
+
+// Remove deletes an entry from the trash
+func (s *Store) Remove(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Purge removes all entries deleted before the given time
+func (s *Store) Purge(before time.Time) (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.DeletedAt.Before(before) {
+			if err := s.Remove(entry.ID); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	return &entry, nil
+}
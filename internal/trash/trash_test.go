@@ -0,0 +1,193 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"ticktick-tui/internal/models"
+	"time"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry Entry
+		title string
+	}{
+		{Entry{Kind: KindTask, Task: &models.Task{ID: "t1", Title: "Buy milk"}}, "Buy milk"},
+		{Entry{Kind: KindProject, Project: &models.ProjectData{
+			Project: models.Project{ID: "p1", Name: "Home"},
+			Tasks:   []models.Task{{ID: "t2", Title: "Clean", Status: models.StatusCompleted}},
+		}}, "Home"},
+	}
+	for _, tt := range tests {
+		entry := tt.entry
+		if err := s.Add(&entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.ID == "" || entry.DeletedAt.IsZero() {
+			t.Fatalf("Add did not assign ID and deletion time: %+v", entry)
+		}
+
+		got, err := s.Get(entry.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Kind != tt.entry.Kind || got.Title() != tt.title {
+			t.Errorf("Get(%s) = %s %q, want %s %q", entry.ID, got.Kind, got.Title(), tt.entry.Kind, tt.title)
+		}
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(tests) {
+		t.Errorf("List returned %d entries, want %d", len(entries), len(tests))
+	}
+}
+
+func TestStoreUpdate(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := &Entry{Kind: KindProject, Project: &models.ProjectData{Project: models.Project{Name: "Home"}}}
+	if err := s.Add(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	entry.RestoredProjectID = "p2"
+	entry.RestoredTaskIDs = []string{"t3"}
+	if err := s.Update(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RestoredProjectID != "p2" || len(got.RestoredTaskIDs) != 1 || got.RestoredTaskIDs[0] != "t3" {
+		t.Errorf("restore progress not kept: %+v", got)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"ab12", "ab34", "cd56"} {
+		writeEntry(t, s, Entry{ID: id, Kind: KindTask, Task: &models.Task{Title: id}, DeletedAt: time.Now()})
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"ab12", "ab12", false},
+		{"cd", "cd56", false},
+		{"ab", "", true},
+		{"ef", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := s.Get(tt.id)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Get(%q) = %s, want error", tt.id, got.ID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Get(%q): %v", tt.id, err)
+			continue
+		}
+		if got.ID != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.id, got.ID, tt.want)
+		}
+	}
+}
+
+func TestStoreRemove(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := &Entry{Kind: KindTask, Task: &models.Task{Title: "x"}}
+	if err := s.Add(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Remove(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(entry.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Remove = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Get(entry.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Remove = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenPurgesExpired(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tests := []struct {
+		id   string
+		age  time.Duration
+		kept bool
+	}{
+		{"fresh", time.Hour, true},
+		{"old", 48 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		writeEntry(t, s, Entry{ID: tt.id, Kind: KindTask, Task: &models.Task{}, DeletedAt: now.Add(-tt.age)})
+	}
+
+	s, err = Open(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		_, err := s.Get(tt.id)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("entry %s kept = %v, want %v", tt.id, kept, tt.kept)
+		}
+	}
+
+	expires, ok := s.ExpiresAt(&Entry{DeletedAt: now})
+	if !ok || !expires.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("ExpiresAt = %v, %v", expires, ok)
+	}
+}
+
+func TestListCorruptEntry(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.path("broken"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(); err == nil {
+		t.Error("List succeeded with a corrupt entry")
+	}
+}
+
+// writeEntry stores e as is, keeping its ID and deletion time
+func writeEntry(t *testing.T, s *Store, e Entry) {
+	t.Helper()
+	if err := s.Update(&e); err != nil {
+		t.Fatal(err)
+	}
+}
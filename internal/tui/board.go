@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Projects with this view mode open in the board instead of the task list
+const kanbanViewMode = "kanban"
+
+// Narrowest a board column gets before fewer columns are shown at once
+const boardColumnWidth = 32
+
+// setColumns keeps the columns of the current project in board order
+func (m *Model) setColumns(columns []models.Column) {
+	m.columns = slices.Clone(columns)
+	slices.SortStableFunc(m.columns, func(a, b models.Column) int {
+		return cmp.Compare(a.SortOrder, b.SortOrder)
+	})
+	m.boardColumn = min(m.boardColumn, len(m.boardColumns())-1)
+	m.scrollBoard()
+}
+
+// boardColumns returns the columns of the board. Projects without columns
+// show all their tasks in a single one.
+func (m *Model) boardColumns() []models.Column {
+	if len(m.columns) == 0 {
+		return []models.Column{{Name: "Tasks"}}
+	}
+	return m.columns
+}
+
+// columnOf returns the index of the column task is in. Tasks outside of any
+// column are shown in the first one.
+func (m *Model) columnOf(task models.Task) int {
+	index := slices.IndexFunc(m.boardColumns(), func(c models.Column) bool { return c.ID == task.ColumnID })
+	return max(index, 0)
+}
+
+// columnTasks returns the tasks in the column at index
+func (m *Model) columnTasks(index int) []models.Task {
+	var tasks []models.Task
+	for _, task := range m.state.Tasks {
+		if m.columnOf(task) == index {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// boardTask returns the selected task of the board, or nil
+func (m *Model) boardTask() *models.Task {
+	tasks := m.columnTasks(m.boardColumn)
+	if m.state.SelectedIndex >= len(tasks) {
+		return nil
+	}
+	return &tasks[m.state.SelectedIndex]
+}
+
+// visibleColumns returns how many columns of the board fit the terminal
+func (m *Model) visibleColumns() int {
+	return max(min(m.width/boardColumnWidth, len(m.boardColumns())), 1)
+}
+
+// scrollBoard scrolls the board sideways as little as possible to keep the
+// selected column in view. It is called whenever the selected column, the
+// columns or the width change.
+func (m *Model) scrollBoard() {
+	visible := m.visibleColumns()
+	m.boardOffset = min(m.boardOffset, m.boardColumn, len(m.boardColumns())-visible)
+	m.boardOffset = max(m.boardOffset, m.boardColumn-visible+1, 0)
+}
+
+// showBoard switches to the board and selects the task at index of the
+// selected column
+func (m *Model) showBoard(index int) {
+	m.state.CurrentView = models.BoardView
+	m.scrollBoard()
+	tasks := m.columnTasks(m.boardColumn)
+	items := make([]any, len(tasks))
+	for i, task := range tasks {
+		items[i] = task
+	}
+	m.state.CurrentItems = items
+	m.state.SelectedIndex = index
+	m.clampSelection(len(tasks))
+}
+
+// toggleBoard switches between the task list and the board of the current
+// project, keeping the selected task
+func (m *Model) toggleBoard() {
+	switch m.state.CurrentView {
+	case models.TaskListView:
+		index := 0
+		if m.state.SelectedIndex < len(m.state.Tasks) {
+			task := m.state.Tasks[m.state.SelectedIndex]
+			m.boardColumn = m.columnOf(task)
+			index = slices.IndexFunc(m.columnTasks(m.boardColumn), func(t models.Task) bool { return t.ID == task.ID })
+		}
+		m.showBoard(index)
+	case models.BoardView:
+		index := 0
+		if task := m.boardTask(); task != nil {
+			index = m.taskIndex(task.ID)
+		}
+		m.showTaskList(index)
+	}
+}
+
+// handleBoardKey handles the actions of the board that act on columns or on
+// the selected task. It reports false for actions it leaves to handleKey.
+func (m *Model) handleBoardKey(a action) (tea.Cmd, bool) {
+	task := m.boardTask()
+	switch a {
+	case actionPreviousColumn:
+		m.boardColumn = max(m.boardColumn-1, 0)
+		m.showBoard(m.state.SelectedIndex)
+	case actionNextColumn:
+		m.boardColumn = min(m.boardColumn+1, len(m.boardColumns())-1)
+		m.showBoard(m.state.SelectedIndex)
+	case actionMoveLeft:
+		return m.moveTask(-1), true
+	case actionMoveRight:
+		return m.moveTask(1), true
+	case actionOpen:
+		if task != nil {
+			m.state.CurrentTask = task
+			return m.navigate(models.TaskDetailView), true
+		}
+	case actionNew:
+		// The task goes into the selected column
+		return m.openTaskForm(nil), true
+	case actionEdit:
+		if task != nil {
+			return m.openTaskForm(task), true
+		}
+	case actionDelete:
+		if task != nil {
+			return m.handleDelete(&deletion{task: task}), true
+		}
+	case actionComplete:
+		if task != nil {
+			return m.toggleComplete(*task), true
+		}
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// moveTask moves the selected task to the next or previous column and keeps
+// it selected. It is moved back if saving fails.
+func (m *Model) moveTask(direction int) tea.Cmd {
+	task := m.boardTask()
+	target := m.boardColumn + direction
+	if task == nil || isPending(*task) || len(m.columns) == 0 || target < 0 || target >= len(m.columns) {
+		return nil
+	}
+	original := *task
+	from := m.boardColumn
+	moved := *task
+	moved.ColumnID = m.columns[target].ID
+	column := m.columns[target].Name
+
+	m.boardColumn = target
+	m.putTask(moved)
+	m.showBoard(slices.IndexFunc(m.columnTasks(target), func(t models.Task) bool { return t.ID == moved.ID }))
+
+	m.state.Error = ""
+	m.state.Loading = true
+	return func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		saved, err := m.client.UpdateTask(moved.ID, &moved)
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			return taskMoveFailedMsg{original: original, column: from, err: err}
+		}
+		return taskMovedMsg{task: saved, column: column}
+	}
+}
+
+// renderBoard shows the tasks of the current project in their columns from
+// the column scrollBoard scrolled to
+func (m *Model) renderBoard() string {
+	columns := m.boardColumns()
+	visible := m.visibleColumns()
+
+	height := max(m.height-4, 3) - paneStyle.GetVerticalFrameSize()
+	listHeight := height - 1 - listStyle.GetVerticalFrameSize()
+	last := m.boardOffset + visible - 1
+
+	views := make([]string, 0, visible)
+	for i := m.boardOffset; i <= last; i++ {
+		style := paneStyle
+		selected := -1
+		if i == m.boardColumn {
+			style = paneFocusedStyle
+			selected = m.state.SelectedIndex
+		}
+		width := m.width / visible
+		if i == last {
+			width = m.width - width*(visible-1)
+		}
+		width -= style.GetHorizontalFrameSize()
+
+		tasks := m.columnTasks(i)
+		header := truncate(columns[i].Name, width-8) + boardCountStyle.Render(fmt.Sprintf(" %d", len(tasks)))
+		// Hint at the columns scrolled out of view
+		if i == m.boardOffset && i > 0 {
+			header = "‹ " + header
+		}
+		if i == last && i < len(columns)-1 {
+			header += " ›"
+		}
+
+		content := lipgloss.JoinVertical(lipgloss.Left,
+			boardHeaderStyle.Render(header),
+			renderTasks(tasks, width, listHeight, selected),
+		)
+		views = append(views, style.Width(width).Height(height).MaxHeight(height+style.GetVerticalFrameSize()).Render(content))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+}
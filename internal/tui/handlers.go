@@ -1,33 +1,618 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"ticktick-tui/internal/auth"
+	"ticktick-tui/internal/client"
 	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/models"
+	"ticktick-tui/internal/trash"
+	"time"
 
 	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func (m *Model) handleKey(key string) tea.Cmd {
-	switch key {
-	case "ctrl+c":
+// How long AuthView waits for the OAuth redirect before asking for manual entry
+const authCallbackTimeout = 5 * time.Minute
+
+func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	a := m.matchKey(msg)
+	if a == "" {
+		return nil
+	}
+	// Any other key cancels a pending logout
+	if a != actionLogout {
+		m.confirmLogout = false
+	}
+
+	if m.showHelp {
+		m.handleHelpKey(a)
+		return nil
+	}
+
+	switch m.state.CurrentView {
+	case models.TaskDetailView:
+		if cmd, ok := m.handleTaskDetailKey(a); ok {
+			return cmd
+		}
+	case models.CreateTaskView, models.CreateProjectView:
+		if cmd, ok := m.handleFormKey(a); ok {
+			return cmd
+		}
+	case models.BoardView:
+		if cmd, ok := m.handleBoardKey(a); ok {
+			return cmd
+		}
+	}
+
+	switch a {
+	case actionQuit:
 		return tea.Quit
-	case "up":
+	case actionUp, actionPreviousField:
 		m.moveSelection(-1)
-	case "down":
+		return m.previewProject()
+	case actionDown, actionNextField:
 		m.moveSelection(1)
-		// case "a":
-		// case "d":
-		// 	return m.handleDelete()
-	case "enter":
+		return m.previewProject()
+	case actionTop:
+		m.state.SelectedIndex = 0
+		return m.previewProject()
+	case actionBottom:
+		m.state.SelectedIndex = max(len(m.state.CurrentItems)-1, 0)
+		return m.previewProject()
+	case actionNextPane:
+		return m.cyclePane(1)
+	case actionPreviousPane:
+		return m.cyclePane(-1)
+	case actionOpen, actionSave, actionConfirm:
 		return m.handleComplete()
+	case actionComplete:
+		if m.state.CurrentView == models.TaskListView && m.state.SelectedIndex < len(m.state.Tasks) {
+			return m.toggleComplete(m.state.Tasks[m.state.SelectedIndex])
+		}
+	case actionNew:
+		switch m.state.CurrentView {
+		case models.ProjectListView:
+			return m.openProjectForm(nil)
+		case models.TaskListView:
+			return m.openTaskForm(nil)
+		}
+	case actionEdit:
+		switch {
+		case m.state.CurrentView == models.ProjectListView && m.state.SelectedIndex < len(m.state.Projects):
+			project := m.state.Projects[m.state.SelectedIndex]
+			return m.openProjectForm(&project)
+		case m.state.CurrentView == models.TaskListView && m.state.SelectedIndex < len(m.state.Tasks):
+			task := m.state.Tasks[m.state.SelectedIndex]
+			return m.openTaskForm(&task)
+		}
+	case actionDelete:
+		switch {
+		case m.state.CurrentView == models.ProjectListView && m.state.SelectedIndex < len(m.state.Projects):
+			project := m.state.Projects[m.state.SelectedIndex]
+			return m.handleDelete(&deletion{project: &project, taskCount: -1})
+		case m.state.CurrentView == models.TaskListView && m.state.SelectedIndex < len(m.state.Tasks):
+			task := m.state.Tasks[m.state.SelectedIndex]
+			return m.handleDelete(&deletion{task: &task})
+		}
+	case actionUndo:
+		return m.handleUndo()
+	case actionBack:
+		return m.back()
+	case actionCancel:
+		m.deleting = nil
+		return m.back()
+	case actionLogout:
+		return m.handleLogout()
+	case actionHelp:
+		m.showHelp = true
+		m.helpView.GotoTop()
+	case actionSearch:
+		return m.navigate(models.SearchView)
+	case actionOpenBrowser:
+		return m.openAuthURL()
+	case actionBoard:
+		m.toggleBoard()
 	}
 	return nil
 }
 
-func (m *Model) handleDelete() (tea.Model, tea.Cmd) {
-	return m, nil
+// handleHelpKey scrolls and closes the help overlay
+func (m *Model) handleHelpKey(a action) {
+	switch a {
+	case actionUp:
+		m.helpView.LineUp(1)
+	case actionDown:
+		m.helpView.LineDown(1)
+	case actionPageUp:
+		m.helpView.ViewUp()
+	case actionPageDown:
+		m.helpView.ViewDown()
+	case actionTop:
+		m.helpView.GotoTop()
+	case actionBottom:
+		m.helpView.GotoBottom()
+	case actionHelp, actionBack:
+		m.showHelp = false
+	}
+}
+
+// handleTaskDetailKey handles the actions of the task detail, which scroll
+// instead of moving a selection. It reports false for actions it leaves to
+// handleKey.
+func (m *Model) handleTaskDetailKey(a action) (tea.Cmd, bool) {
+	task := m.state.CurrentTask
+	switch a {
+	case actionUp:
+		m.detail.LineUp(1)
+	case actionDown:
+		m.detail.LineDown(1)
+	case actionPageUp:
+		m.detail.ViewUp()
+	case actionPageDown:
+		m.detail.ViewDown()
+	case actionTop:
+		m.detail.GotoTop()
+	case actionBottom:
+		m.detail.GotoBottom()
+	case actionBack:
+		// The task may have been completed meanwhile
+		cmd := m.back()
+		return tea.Batch(cmd, m.loadTasks()), true
+	case actionComplete:
+		if task != nil {
+			return m.toggleComplete(*task), true
+		}
+	case actionEdit:
+		if task != nil {
+			return m.openTaskForm(task), true
+		}
+	case actionDelete:
+		if task != nil {
+			return m.handleDelete(&deletion{task: task}), true
+		}
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// handleFormKey handles the actions of the task and project form fields
+// that are not text inputs. It reports false for actions it leaves to
+// handleKey.
+func (m *Model) handleFormKey(a action) (tea.Cmd, bool) {
+	index := m.state.SelectedIndex
+	switch field := m.state.CurrentItems[index].(type) {
+	case choiceField:
+		if !field.handleKey(a) {
+			return nil, false
+		}
+		m.state.CurrentItems[index] = field
+	case colorField:
+		if !field.handleKey(a) {
+			return nil, false
+		}
+		m.state.CurrentItems[index] = field
+	case toggleField:
+		switch a {
+		case actionToggle:
+			field.on = !field.on
+		default:
+			return nil, false
+		}
+		m.state.CurrentItems[index] = field
+	case dateField:
+		if !field.handleKey(a) {
+			return nil, false
+		}
+		m.state.CurrentItems[index] = field
+	default:
+		return nil, false
+	}
+	delete(m.formErrors, index)
+	return nil, true
+}
+
+// openTaskForm opens the task form to edit task, or to create a task in the
+// current project if task is nil
+func (m *Model) openTaskForm(task *models.Task) tea.Cmd {
+	if task != nil && isPending(*task) {
+		return nil
+	}
+	m.editTask = task
+	m.formErrors = nil
+	return m.navigate(models.CreateTaskView)
+}
+
+// openProjectForm opens the project form to edit project, or to create a
+// project if project is nil
+func (m *Model) openProjectForm(project *models.Project) tea.Cmd {
+	m.editProject = project
+	m.formErrors = nil
+	return m.navigate(models.CreateProjectView)
+}
+
+// submitTaskForm validates the task form and saves the task. The list is
+// updated right away and put back if saving fails.
+func (m *Model) submitTaskForm() tea.Cmd {
+	var base models.Task
+	if m.editTask != nil {
+		base = *m.editTask
+	}
+	task, errs := taskFromForm(m.state.CurrentItems, base)
+	m.formErrors = errs
+	if len(errs) > 0 {
+		// Move to the first invalid field
+		for i := range m.state.CurrentItems {
+			if _, ok := errs[i]; ok {
+				m.state.SelectedIndex = i
+				break
+			}
+		}
+		return nil
+	}
+
+	if m.editTask != nil {
+		return m.saveTask(*m.editTask, *task)
+	}
+	// Tasks created on the board go into the selected column
+	if len(m.history) > 0 && m.history[len(m.history)-1].view == models.BoardView &&
+		m.state.CurrentProject != nil && task.ProjectID == m.state.CurrentProject.ID {
+		task.ColumnID = m.boardColumns()[m.boardColumn].ID
+	}
+	return m.createTask(*task)
+}
+
+// Prefix of the IDs of placeholder tasks
+const pendingTaskPrefix = "pending-"
+
+// isPending reports whether task is a placeholder for a task being created,
+// which cannot be changed yet
+func isPending(task models.Task) bool {
+	return strings.HasPrefix(task.ID, pendingTaskPrefix)
+}
+
+// createTask inserts a placeholder for task into the list and creates it
+func (m *Model) createTask(task models.Task) tea.Cmd {
+	m.pendingTasks++
+	pendingID := fmt.Sprintf("%s%d", pendingTaskPrefix, m.pendingTasks)
+
+	cmd := m.back()
+	if m.state.CurrentProject != nil && task.ProjectID == m.state.CurrentProject.ID {
+		placeholder := task
+		placeholder.ID = pendingID
+		m.state.Tasks = append(m.state.Tasks, placeholder)
+		switch m.state.CurrentView {
+		case models.TaskListView:
+			m.showTaskList(len(m.state.Tasks) - 1)
+		case models.BoardView:
+			m.showBoard(len(m.columnTasks(m.boardColumn)) - 1)
+		}
+	}
+
+	m.state.Loading = true
+	return tea.Batch(cmd, func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		created, err := m.client.CreateTask(&task)
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			return taskCreateFailedMsg{pendingID: pendingID, err: err}
+		}
+		return taskCreatedMsg{pendingID: pendingID, task: created}
+	})
+}
+
+// saveTask shows the edited task right away and saves it, putting back
+// original if that fails
+func (m *Model) saveTask(original, task models.Task) tea.Cmd {
+	m.putTask(task)
+	cmd := m.back()
+
+	m.state.Loading = true
+	return tea.Batch(cmd, func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		saved, err := m.client.UpdateTask(task.ID, &task)
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			return taskSaveFailedMsg{original: original, err: err}
+		}
+		return taskSavedMsg(saved)
+	})
+}
+
+// submitProjectForm validates the project form and creates or updates the
+// project
+func (m *Model) submitProjectForm() tea.Cmd {
+	var base models.Project
+	if m.editProject != nil {
+		base = *m.editProject
+	}
+	project, errs := projectFromForm(m.state.CurrentItems, base)
+	m.formErrors = errs
+	if len(errs) > 0 {
+		return nil
+	}
+
+	editing := m.editProject != nil
+	cmd := m.back()
+
+	m.state.Loading = true
+	return tea.Batch(cmd, func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		var saved *models.Project
+		var err error
+		if editing {
+			saved, err = m.client.UpdateProject(project.ID, project)
+		} else {
+			saved, err = m.client.CreateProject(project)
+		}
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			m.state.Error = "保存项目失败：" + err.Error()
+			return nil
+		}
+		return projectSavedMsg(saved)
+	})
+}
+
+// putTask replaces the loaded copies of a task
+func (m *Model) putTask(task models.Task) {
+	for i := range m.state.Tasks {
+		if m.state.Tasks[i].ID == task.ID {
+			m.state.Tasks[i] = task
+		}
+	}
+	if m.state.CurrentTask != nil && m.state.CurrentTask.ID == task.ID {
+		m.state.CurrentTask = &task
+	}
+	if m.state.CurrentView == models.BoardView {
+		// The task may have changed columns
+		m.showBoard(m.state.SelectedIndex)
+	}
+}
+
+// replacePendingTask swaps the placeholder of a task being created for the
+// created task, or removes it if task has no ID. The created task is added
+// if the list was reloaded in the meantime.
+func (m *Model) replacePendingTask(pendingID string, task models.Task) {
+	index := slices.IndexFunc(m.state.Tasks, func(t models.Task) bool { return t.ID == pendingID })
+	switch {
+	case index >= 0 && task.ID == "":
+		m.state.Tasks = slices.Delete(m.state.Tasks, index, index+1)
+	case index >= 0:
+		m.state.Tasks[index] = task
+	case task.ID != "" && m.state.CurrentProject != nil && task.ProjectID == m.state.CurrentProject.ID &&
+		!slices.ContainsFunc(m.state.Tasks, func(t models.Task) bool { return t.ID == task.ID }):
+		m.state.Tasks = append(m.state.Tasks, task)
+	}
+
+	switch m.state.CurrentView {
+	case models.TaskListView:
+		m.showTaskList(m.state.SelectedIndex)
+	case models.BoardView:
+		m.showBoard(m.state.SelectedIndex)
+	}
+}
+
+// showProjectList switches to the loaded project list and selects the
+// project at index
+func (m *Model) showProjectList(index int) {
+	m.state.CurrentView = models.ProjectListView
+	items := make([]any, len(m.state.Projects))
+	for i, project := range m.state.Projects {
+		items[i] = project
+	}
+	m.state.CurrentItems = items
+	m.state.SelectedIndex = index
+	m.clampSelection(len(m.state.Projects))
+}
+
+// showTaskList switches to the loaded task list and selects the task at
+// index
+func (m *Model) showTaskList(index int) {
+	m.state.CurrentView = models.TaskListView
+	items := make([]any, len(m.state.Tasks))
+	for i, task := range m.state.Tasks {
+		items[i] = task
+	}
+	m.state.CurrentItems = items
+	m.state.SelectedIndex = index
+	m.clampSelection(len(m.state.Tasks))
+}
+
+// toggleComplete completes a task, or reopens it if it is already completed
+func (m *Model) toggleComplete(task models.Task) tea.Cmd {
+	if isPending(task) {
+		return nil
+	}
+	m.state.Error = ""
+	m.state.Message = ""
+	m.state.Loading = true
+	return func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		var err error
+		reopen := task.Status == models.StatusCompleted
+		if reopen {
+			err = m.client.ReopenTask(task.ProjectID, task.ID)
+		} else {
+			err = m.client.CompleteTask(task.ProjectID, task.ID)
+		}
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			m.state.Error = "更新任务状态失败：" + err.Error()
+			return nil
+		}
+
+		// Pick up the completion time set by the server
+		updated, err := m.client.GetTask(task.ProjectID, task.ID)
+		if err != nil {
+			updated = &task
+			updated.Status = models.StatusCompleted
+			updated.CompletedTime = &models.TickTickTime{Time: time.Now()}
+			if reopen {
+				updated.Status = models.StatusNormal
+				updated.CompletedTime = nil
+			}
+		}
+		return taskUpdatedMsg(updated)
+	}
+}
+
+// handleLogout signs the active profile out after the key is pressed a
+// second time
+func (m *Model) handleLogout() tea.Cmd {
+	if !m.confirmLogout {
+		m.confirmLogout = true
+		m.state.Error = ""
+		m.state.Message = fmt.Sprintf("再次按 %s 退出配置档案 %s", m.keyName(actionLogout), core.ActiveProfile())
+		return nil
+	}
+	m.confirmLogout = false
+
+	// Writes the config file, so not in a command
+	if err := core.Logout(core.ActiveProfile()); err != nil {
+		m.state.Error = "退出登录失败：" + err.Error()
+		return nil
+	}
+	return func() tea.Msg { return loggedOutMsg{} }
+}
+
+// deletion is a task or project waiting for confirmation in
+// DeleteConfirmView
+type deletion struct {
+	task    *models.Task
+	project *models.Project
+	// Tasks in the project, -1 until they are counted
+	taskCount int
+}
+
+// How long a deletion can be undone
+const undoSeconds = 8
+
+// handleDelete asks for confirmation before deleting a task or project.
+// Projects are loaded to show how many tasks go with them.
+func (m *Model) handleDelete(d *deletion) tea.Cmd {
+	if d.task != nil && isPending(*d.task) {
+		return nil
+	}
+	m.deleting = d
+	m.navigate(models.DeleteConfirmView)
+
+	if d.project == nil {
+		return nil
+	}
+	projectID := d.project.ID
+	return func() tea.Msg {
+		data, err := m.client.GetProjectData(projectID)
+		if err != nil {
+			// Only the count is missing, deleting still works
+			return nil
+		}
+		return deleteCountedMsg{projectID: projectID, count: len(data.Tasks)}
+	}
+}
+
+// confirmDelete moves the task or project to the trash. It can be restored
+// with u for a few seconds afterwards.
+func (m *Model) confirmDelete() tea.Cmd {
+	d := m.deleting
+	m.deleting = nil
+	m.back()
+	if m.state.CurrentView == models.TaskDetailView {
+		// The task is about to disappear
+		m.back()
+	}
+
+	m.state.Loading = true
+	return func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		var entry *trash.Entry
+		var err error
+		if d.task != nil {
+			entry, err = core.DeleteTask(m.client, d.task.ProjectID, d.task.ID)
+		} else {
+			entry, err = core.DeleteProject(m.client, d.project.ID)
+		}
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			m.state.Error = "删除失败：" + err.Error()
+			return nil
+		}
+		return deletedMsg{entry: entry}
+	}
+}
+
+// startUndo offers to restore a trashed entry for undoSeconds
+func (m *Model) startUndo(entry *trash.Entry) tea.Cmd {
+	m.undoID++
+	m.undoEntry = entry
+	m.undoLeft = undoSeconds
+	return m.undoTick()
+}
+
+func (m *Model) undoTick() tea.Cmd {
+	id := m.undoID
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return undoTickMsg{id: id}
+	})
+}
+
+// handleUndo recreates the last deleted task or project from its snapshot
+func (m *Model) handleUndo() tea.Cmd {
+	entry := m.undoEntry
+	m.undoEntry = nil
+
+	m.state.Loading = true
+	return func() tea.Msg {
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		restored, err := core.RestoreFromTrash(m.client, entry.ID)
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
+		if err != nil {
+			m.state.Error = "撤销删除失败：" + err.Error()
+			return nil
+		}
+		return restoredMsg{entry: restored}
+	}
 }
 
 func (m *Model) handleComplete() tea.Cmd {
@@ -36,32 +621,31 @@ func (m *Model) handleComplete() tea.Cmd {
 	m.state.Loading = true // Set loading state
 	switch m.state.CurrentView {
 	case models.ConfigView:
-		return func() tea.Msg {
-			defer func() {
-				m.state.Loading = false
-			}()
+		m.state.Loading = false
 
-			// CurrentItems -> configInputs
-			for i, input := range m.state.CurrentItems {
-				if textInput, ok := input.(textinput.Model); ok {
-					m.configInputs[i].SetValue(textInput.Value())
-				}
+		// CurrentItems -> configInputs
+		for i, input := range m.state.CurrentItems {
+			if textInput, ok := input.(textinput.Model); ok {
+				m.configInputs[i].SetValue(textInput.Value())
 			}
-			// Validate inputs
-			for _, input := range m.configInputs {
-				if input.Value() == "" {
-					m.state.Error = "All fields must be filled out."
-					return nil
-				}
+		}
+		// Validate inputs
+		for _, input := range m.configInputs {
+			if input.Value() == "" {
+				m.state.Error = "All fields must be filled out."
+				return nil
 			}
+		}
 
-			// Save configuration
-			core.SaveConfig("client_id", m.configInputs[0].Value())
-			core.SaveConfig("client_secret", m.configInputs[1].Value())
-			core.SaveConfig("redirect_uri", m.configInputs[2].Value())
-
-			return configSavedMsg{}
+		// Save configuration. The config file is only written from here,
+		// not from commands running alongside.
+		for i, key := range []string{"client_id", "client_secret", "redirect_uri"} {
+			if err := core.SaveConfig(key, m.configInputs[i].Value()); err != nil {
+				m.state.Error = err.Error()
+				return nil
+			}
 		}
+		return func() tea.Msg { return configSavedMsg{} }
 
 	case models.AuthView:
 		return func() tea.Msg {
@@ -78,31 +662,129 @@ func (m *Model) handleComplete() tea.Cmd {
 			// Validate inputs
 			for _, input := range m.authInputs {
 				if input.Value() == "" {
-					m.state.Error = "Authorization code must be filled out."
+					m.state.Error = "Redirect URL must be filled out."
 					return nil
 				}
 			}
 
-			// Exchange authorization code for access token
-			accessToken, err := core.GetToken(m.authInputs[0].Value())
-			if err != nil {
-				m.state.Error = "Failed to exchange authorization code: " + err.Error()
-				return nil
-			}
-
-			// Save authorization code
-			core.SaveConfig("access_token", accessToken.AccessToken)
-
-			return tokenExchangedMsg{}
+			code, state := core.ParseAuthResponse(m.authInputs[0].Value())
+			return m.exchangeAuthCode(code, state)()
 		}
 
 	case models.ProjectListView:
 		m.state.Loading = false
-		return m.changeView(models.TaskListView)
+		if m.state.SelectedIndex < len(m.state.Projects) && m.state.Projects[m.state.SelectedIndex].ViewMode == kanbanViewMode {
+			return m.navigate(models.BoardView)
+		}
+		if m.splitLayout() {
+			// The tasks are already shown next to the projects
+			return tea.Batch(m.previewProject(), m.focusPane(models.TaskListView))
+		}
+		return m.navigate(models.TaskListView)
+
+	case models.CreateTaskView:
+		m.state.Loading = false
+		return m.submitTaskForm()
+
+	case models.CreateProjectView:
+		m.state.Loading = false
+		return m.submitProjectForm()
+
+	case models.DeleteConfirmView:
+		m.state.Loading = false
+		return m.confirmDelete()
+
+	case models.SearchView:
+		m.state.Loading = false
+		return m.openSearchResult()
+
+	case models.TaskListView:
+		m.state.Loading = false
+		if m.state.SelectedIndex >= len(m.state.Tasks) {
+			return nil
+		}
+		task := m.state.Tasks[m.state.SelectedIndex]
+		m.state.CurrentTask = &task
+		return m.navigate(models.TaskDetailView)
 	}
+	m.state.Loading = false
 	return nil
 }
 
+// exchangeAuthCode exchanges an authorization code for an access token,
+// which is saved when the result arrives. The state is verified against the
+// pending login.
+func (m *Model) exchangeAuthCode(code, state string) tea.Cmd {
+	return func() tea.Msg {
+		m.state.Loading = true
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		token, err := core.ExchangeAuthCode(code, state)
+		if err != nil {
+			m.state.Error = "Failed to exchange authorization code: " + err.Error()
+			return nil
+		}
+
+		return tokenExchangedMsg{token: token}
+	}
+}
+
+// newClient creates the API client that all commands share. Tokens it
+// renews are saved when they arrive as tokenRefreshedMsg.
+func (m *Model) newClient() *client.Client {
+	return core.NewClientWithTokenSaver(func(token *models.OAuthToken) error {
+		m.tokenSaves <- token
+		return nil
+	})
+}
+
+// waitForTokenSave delivers the next token renewed by the client
+func (m *Model) waitForTokenSave() tea.Cmd {
+	return func() tea.Msg {
+		return tokenRefreshedMsg{token: <-m.tokenSaves}
+	}
+}
+
+// listenForAuthCode waits for the OAuth redirect on the configured
+// redirect_uri. Manual entry of the code keeps working alongside it.
+func (m *Model) listenForAuthCode() tea.Cmd {
+	m.stopAuthCallback()
+
+	server, err := core.ListenForCallback()
+	if err != nil {
+		// Not a loopback redirect URI, only manual entry is possible
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authCallbackTimeout)
+	m.cancelAuthCallback = func() {
+		cancel()
+		// Free the port right away for the listener of the next login
+		server.Close()
+	}
+
+	return func() tea.Msg {
+		defer cancel()
+		code, state, err := server.Wait(ctx)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return authCallbackFailedMsg{err: err}
+		}
+		return authCodeReceivedMsg{code: code, state: state}
+	}
+}
+
+func (m *Model) stopAuthCallback() {
+	if m.cancelAuthCallback != nil {
+		m.cancelAuthCallback()
+		m.cancelAuthCallback = nil
+	}
+}
+
 func (m *Model) moveSelection(direction int) {
 	maxIndex := len(m.state.CurrentItems) - 1
 
@@ -115,8 +797,160 @@ func (m *Model) moveSelection(direction int) {
 	}
 }
 
+// traceMsg logs the messages the TUI handles at debug level. Spinner and
+// cursor ticks are too frequent to be useful, and keys typed into inputs
+// are what the user wrote, which may be credentials.
+func (m *Model) traceMsg(msg tea.Msg) {
+	switch msg.(type) {
+	case spinner.TickMsg, cursor.BlinkMsg:
+		return
+	}
+
+	view := m.state.CurrentView
+	attrs := []any{"type", fmt.Sprintf("%T", msg), "view", view}
+	if key, ok := msg.(tea.KeyMsg); ok && !m.typing() && view != models.ConfigView && view != models.AuthView {
+		attrs = append(attrs, "key", key.String())
+	}
+	slog.Debug("tui message", attrs...)
+}
+
+// logError logs errors shown to the user, once each
+func (m *Model) logError() {
+	if m.state.Error != "" && m.state.Error != m.loggedError {
+		slog.Warn("tui error", "view", m.state.CurrentView, "error", m.state.Error)
+	}
+	m.loggedError = m.state.Error
+}
+
+// clampSelection keeps the selection inside a list that was reloaded
+func (m *Model) clampSelection(length int) {
+	if m.state.SelectedIndex >= length {
+		m.state.SelectedIndex = length - 1
+	}
+	if m.state.SelectedIndex < 0 {
+		m.state.SelectedIndex = 0
+	}
+}
+
+// waitForConfigReload delivers the next change of the configuration file
+func (m *Model) waitForConfigReload() tea.Cmd {
+	return func() tea.Msg {
+		<-m.configReloads
+		return configChangedMsg{}
+	}
+}
+
+// applyTheme applies the configured colors
+func (m *Model) applyTheme() {
+	applyTheme(core.CurrentConfig().TUI.Colors)
+	m.spinner.Style = spinnerStyle
+	m.help.Styles.FullKey = helpOverlayKeyStyle
+	m.help.Styles.FullDesc = helpOverlayDescStyle
+	m.help.Styles.FullSeparator = helpOverlayDescStyle
+}
+
+// applyKeys binds the keys of the configured keymap
+func (m *Model) applyKeys() {
+	cfg := core.CurrentConfig().TUI
+	m.keys = newKeyMap(cfg.Keymap, cfg.Keys)
+	m.keyPrefix = ""
+}
+
+// applyConfig re-applies the settings after the config file was reloaded
+func (m *Model) applyConfig() tea.Cmd {
+	m.applyTheme()
+	m.applyKeys()
+
+	// Credentials, token or region of the profile may have changed
+	switch m.state.CurrentView {
+	case models.ConfigView:
+		// Don't overwrite what is being typed
+	case models.AuthView:
+		cmd := m.startAuth()
+		m.refreshGen++
+		return tea.Batch(cmd, m.scheduleRefresh())
+	default:
+		if m.client = m.newClient(); m.client == nil {
+			return m.changeView(models.AuthView)
+		}
+	}
+
+	m.refreshGen++
+	return m.scheduleRefresh()
+}
+
+// scheduleRefresh reloads the open list after the configured interval
+func (m *Model) scheduleRefresh() tea.Cmd {
+	interval := core.CurrentConfig().TUI.RefreshInterval
+	if interval <= 0 {
+		return nil
+	}
+	gen := m.refreshGen
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return refreshTickMsg{gen: gen}
+	})
+}
+
+// refreshList reloads the open list in the background, keeping the selection
+func (m *Model) refreshList() tea.Cmd {
+	if m.state.Loading {
+		return nil
+	}
+	switch m.state.CurrentView {
+	case models.ProjectListView:
+		return m.loadProjects()
+	case models.TaskListView, models.BoardView:
+		return m.loadTasks()
+	}
+	return nil
+}
+
+// How long notices stay in the status bar
+const noticeDuration = 5 * time.Second
+
+// showNotice shows a message in the status bar for a few seconds
+func (m *Model) showNotice(text string, isError bool) tea.Cmd {
+	m.noticeID++
+	m.notice = text
+	m.noticeError = isError
+
+	id := m.noticeID
+	return tea.Tick(noticeDuration, func(time.Time) tea.Msg {
+		return clearNoticeMsg{id: id}
+	})
+}
+
+// startAuth starts a new login and listens for its redirect. The listener of
+// the previous login is stopped, as its code no longer matches the session.
+func (m *Model) startAuth() tea.Cmd {
+	m.stopAuthCallback()
+	m.generateAuthURL()
+	if m.state.AuthURL == "" {
+		return nil
+	}
+	return m.listenForAuthCode()
+}
+
+// openAuthURL opens the authorization URL in the browser and copies it
+func (m *Model) openAuthURL() tea.Cmd {
+	if m.state.CurrentView != models.AuthView || m.state.AuthURL == "" {
+		return nil
+	}
+	clipboard.WriteAll(m.state.AuthURL)
+	if err := auth.OpenBrowser(m.state.AuthURL); err != nil {
+		return m.showNotice("无法打开浏览器："+err.Error(), true)
+	}
+	return m.showNotice("已在浏览器中打开，链接已复制到剪贴板", false)
+}
+
 func (m *Model) generateAuthURL() {
-	m.state.AuthURL = core.GetAuthURL()
+	m.state.AuthURL = ""
+	authURL, err := core.GetAuthURL()
+	if err != nil {
+		m.state.Error = err.Error()
+		return
+	}
+	m.state.AuthURL = authURL
 }
 
 func (m *Model) resetForm() {
@@ -132,12 +966,19 @@ func (m *Model) resetForm() {
 }
 
 func (m *Model) loadProjects() tea.Cmd {
+	c := m.client
 	return func() tea.Msg {
 		m.state.Loading = true
 		defer func() {
 			m.state.Loading = false
 		}()
-		projects, err := core.GetProjects()
+		if c == nil {
+			return unauthorizedMsg{}
+		}
+		projects, err := c.GetProjects()
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
 		if err != nil {
 			m.state.Error = "Failed to load projects: " + err.Error()
 			return nil
@@ -162,26 +1003,92 @@ func (m *Model) loadProjects() tea.Cmd {
 }
 
 func (m *Model) loadTasks() tea.Cmd {
+	c := m.client
+	project := m.state.CurrentProject
 	return func() tea.Msg {
 		m.state.Loading = true
 		defer func() {
 			m.state.Loading = false
 		}()
-		if m.state.CurrentProject == nil {
+		if project == nil {
 			m.state.Error = "No project selected."
 			return nil
 		}
-		tasks, err := core.GetTasks(m.state.CurrentProject.ID)
+		if c == nil {
+			return unauthorizedMsg{}
+		}
+		data, err := c.GetProjectData(project.ID)
+		if client.IsUnauthorized(err) {
+			return unauthorizedMsg{}
+		}
 		if err != nil {
 			m.state.Error = "Failed to load tasks: " + err.Error()
 			return nil
 		}
-		return tasksLoadedMsg(tasks)
+		return tasksLoadedMsg{projectID: project.ID, tasks: data.Tasks, columns: data.Columns}
 	}
 }
 
+// navEntry is a view on the navigation stack with the selection and scroll
+// position to restore when going back to it
+type navEntry struct {
+	view     models.ViewState
+	selected int
+	offset   int
+}
+
+// navigate opens a view, remembering the current one for back
+func (m *Model) navigate(view models.ViewState) tea.Cmd {
+	m.history = append(m.history, navEntry{
+		view:     m.state.CurrentView,
+		selected: m.state.SelectedIndex,
+		offset:   m.detail.YOffset,
+	})
+	return m.changeView(view)
+}
+
+// back returns to the previous view without reloading it. Without history
+// the task list goes back to the projects.
+func (m *Model) back() tea.Cmd {
+	m.formErrors = nil
+	if len(m.history) == 0 {
+		if m.state.CurrentView == models.TaskListView || m.state.CurrentView == models.BoardView {
+			return m.changeView(models.ProjectListView)
+		}
+		return nil
+	}
+
+	entry := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	switch entry.view {
+	case models.ProjectListView:
+		m.showProjectList(entry.selected)
+	case models.TaskListView:
+		m.showTaskList(entry.selected)
+	case models.BoardView:
+		m.showBoard(entry.selected)
+	case models.TaskDetailView:
+		m.state.CurrentView = models.TaskDetailView
+		m.state.CurrentItems = []any{}
+		m.state.SelectedIndex = 0
+		m.detail.SetYOffset(entry.offset)
+	default:
+		return m.changeView(entry.view)
+	}
+	return nil
+}
+
 func (m *Model) changeView(view models.ViewState) tea.Cmd {
+	if view != models.AuthView {
+		m.stopAuthCallback()
+	}
+	switch view {
+	case models.ConfigView, models.AuthView, models.ProjectListView:
+		// Top-level views start a new history
+		m.history = nil
+	}
 	m.state.CurrentView = view
+	m.showHelp = false
 	defer func() {
 		m.state.SelectedIndex = 0
 	}()
@@ -196,22 +1103,22 @@ func (m *Model) changeView(view models.ViewState) tea.Cmd {
 		m.state.CurrentItems = items
 
 	case models.AuthView:
-		m.generateAuthURL()
-		// Copy to clipboard
-		clipboard.WriteAll(m.state.AuthURL)
 		m.resetForm()
 		items := make([]any, len(m.authInputs))
 		for i, input := range m.authInputs {
 			items[i] = input
 		}
 		m.state.CurrentItems = items
+		// The browser is only opened on request, this view is also shown
+		// when the token expired
+		return m.startAuth()
 
 	case models.ProjectListView:
 		m.resetForm()
 		m.state.CurrentItems = []any{}
 		return m.loadProjects()
 
-	case models.TaskListView:
+	case models.TaskListView, models.BoardView:
 		m.resetForm()
 		if len(m.state.Projects) == 0 {
 			m.state.Error = "No projects available."
@@ -223,17 +1130,164 @@ func (m *Model) changeView(view models.ViewState) tea.Cmd {
 		}
 		m.state.CurrentProject = &m.state.Projects[m.state.SelectedIndex]
 		m.state.CurrentItems = []any{}
+		m.columns = nil
+		m.boardColumn = 0
+		m.boardOffset = 0
 		return m.loadTasks()
 
 	case models.TaskDetailView:
-		// Show task details here
+		if m.state.CurrentTask == nil {
+			m.state.Error = "No task selected."
+			return nil
+		}
+		m.detail.SetContent("")
+		m.detail.GotoTop()
 	case models.CreateTaskView:
-		// Show create task form here
+		projectID := ""
+		if m.state.CurrentProject != nil {
+			projectID = m.state.CurrentProject.ID
+		}
+		m.state.CurrentItems = newTaskForm(m.editTask, m.state.Projects, projectID)
 	case models.CreateProjectView:
-		// Show create project form here
+		m.state.CurrentItems = newProjectForm(m.editProject, m.state.Projects)
 	case models.DeleteConfirmView:
-		// Show delete confirmation here
+		if m.deleting == nil {
+			m.state.Error = "Nothing to delete."
+			return nil
+		}
+	case models.SearchView:
+		m.searchInput.SetValue("")
+		m.searchQuery = ""
+		m.searchResults = nil
+		// Tasks seen before are searched right away, the rest is loaded
+		tasks, missing := m.cachedSearchTasks()
+		m.searchTasks = tasks
+		if len(missing) == 0 && tasks == nil {
+			m.searchTasks = []models.Task{}
+		}
+		return tea.Batch(m.searchInput.Focus(), m.loadSearchTasks(missing))
 	}
 
 	return nil
 }
+
+// searchLoadLimit is how many projects are loaded at once for the search
+const searchLoadLimit = 4
+
+// cachedSearchTasks returns the known tasks of the open projects, and the
+// open projects whose tasks have to be loaded for the search
+func (m *Model) cachedSearchTasks() (tasks []models.Task, missing []models.Project) {
+	for _, project := range m.state.Projects {
+		if project.Closed {
+			continue
+		}
+		if m.state.CurrentProject != nil && m.state.CurrentProject.ID == project.ID && m.state.Tasks != nil {
+			// Newer than the cache if tasks were edited since
+			tasks = append(tasks, m.state.Tasks...)
+			continue
+		}
+		cached, ok := m.taskCache[project.ID]
+		if !ok {
+			missing = append(missing, project)
+			continue
+		}
+		tasks = append(tasks, cached...)
+	}
+	return tasks, missing
+}
+
+// loadSearchTasks loads the tasks of projects for the search. Projects that
+// fail to load are left out of it.
+func (m *Model) loadSearchTasks(projects []models.Project) tea.Cmd {
+	if len(projects) == 0 {
+		return nil
+	}
+	c := m.client
+	return func() tea.Msg {
+		if c == nil {
+			return unauthorizedMsg{}
+		}
+		m.state.Loading = true
+		defer func() {
+			m.state.Loading = false
+		}()
+
+		var (
+			mu           sync.Mutex
+			wg           sync.WaitGroup
+			unauthorized bool
+		)
+		loaded := searchTasksLoadedMsg{tasks: make(map[string][]models.Task, len(projects))}
+		limit := make(chan struct{}, searchLoadLimit)
+		for _, project := range projects {
+			wg.Add(1)
+			limit <- struct{}{}
+			go func() {
+				defer func() {
+					<-limit
+					wg.Done()
+				}()
+				data, err := c.GetProjectData(project.ID)
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case client.IsUnauthorized(err):
+					unauthorized = true
+				case err != nil:
+					slog.Warn("loading tasks for search failed", "project", project.ID, "error", err)
+					loaded.failed = append(loaded.failed, project.Name)
+				default:
+					loaded.tasks[project.ID] = data.Tasks
+				}
+			}()
+		}
+		wg.Wait()
+
+		if unauthorized {
+			return unauthorizedMsg{}
+		}
+		return loaded
+	}
+}
+
+// updateSearch ranks the loaded tasks against the search prompt
+func (m *Model) updateSearch() {
+	m.searchQuery = m.searchInput.Value()
+	m.searchResults = searchTasks(m.searchQuery, m.searchTasks, m.state.Projects)
+	items := make([]any, len(m.searchResults))
+	for i, result := range m.searchResults {
+		items[i] = result
+	}
+	m.state.CurrentItems = items
+	m.state.SelectedIndex = 0
+}
+
+// openSearchResult shows the task of the selected result as if it had been
+// opened from its project, so that Esc leads to the project's tasks
+func (m *Model) openSearchResult() tea.Cmd {
+	if m.state.SelectedIndex >= len(m.searchResults) {
+		return nil
+	}
+	task := m.searchResults[m.state.SelectedIndex].task
+	projectIndex := slices.IndexFunc(m.state.Projects, func(p models.Project) bool { return p.ID == task.ProjectID })
+	if projectIndex < 0 {
+		return nil
+	}
+
+	m.state.CurrentProject = &m.state.Projects[projectIndex]
+	m.state.Tasks = nil
+	for _, t := range m.searchTasks {
+		if t.ProjectID == task.ProjectID {
+			m.state.Tasks = append(m.state.Tasks, t)
+		}
+	}
+	taskIndex := slices.IndexFunc(m.state.Tasks, func(t models.Task) bool { return t.ID == task.ID })
+	m.state.CurrentTask = &task
+
+	m.history = []navEntry{
+		{view: models.ProjectListView, selected: projectIndex},
+		{view: models.TaskListView, selected: taskIndex},
+	}
+	return m.changeView(models.TaskDetailView)
+}
@@ -0,0 +1,532 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"ticktick-tui/internal/models"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// action is something a key can be bound to. The names are used in
+// tui.keys in the config file.
+type action string
+
+const (
+	actionQuit     action = "quit"
+	actionUp       action = "up"
+	actionDown     action = "down"
+	actionTop      action = "top"
+	actionBottom   action = "bottom"
+	actionPageUp   action = "page_up"
+	actionPageDown action = "page_down"
+	actionOpen     action = "open"
+	actionBack     action = "back"
+	actionNew      action = "new"
+	actionEdit     action = "edit"
+	actionDelete   action = "delete"
+	actionComplete action = "complete"
+	actionUndo     action = "undo"
+	actionLogout   action = "logout"
+	actionHelp     action = "help"
+	actionSearch   action = "search"
+
+	// Login
+	actionOpenBrowser action = "open_browser"
+
+	// Panes of the split layout on wide terminals
+	actionNextPane     action = "next_pane"
+	actionPreviousPane action = "previous_pane"
+
+	// Kanban board
+	actionBoard          action = "board"
+	actionPreviousColumn action = "previous_column"
+	actionNextColumn     action = "next_column"
+	actionMoveLeft       action = "move_left"
+	actionMoveRight      action = "move_right"
+
+	// Forms and dialogs
+	actionNextField     action = "next_field"
+	actionPreviousField action = "previous_field"
+	actionSave          action = "save"
+	actionCancel        action = "cancel"
+	actionConfirm       action = "confirm"
+
+	// Form fields that are not text inputs
+	actionPreviousOption action = "previous_option"
+	actionNextOption     action = "next_option"
+	actionToggle         action = "toggle"
+	actionPreviousDay    action = "previous_day"
+	actionNextDay        action = "next_day"
+	actionPreviousMonth  action = "previous_month"
+	actionNextMonth      action = "next_month"
+	actionEarlier        action = "earlier"
+	actionLater          action = "later"
+	actionToday          action = "today"
+	actionClear          action = "clear"
+)
+
+// Keys are named as tea.KeyMsg prints them, except that the space bar is
+// "space". Keys separated by spaces form a sequence, e.g. "g g".
+var defaultKeys = map[action][]string{
+	actionQuit:     {"ctrl+c"},
+	actionUp:       {"up"},
+	actionDown:     {"down"},
+	actionTop:      {"home"},
+	actionBottom:   {"end"},
+	actionPageUp:   {"pgup"},
+	actionPageDown: {"pgdown"},
+	actionOpen:     {"enter"},
+	actionBack:     {"esc"},
+	actionNew:      {"a"},
+	actionEdit:     {"e"},
+	actionDelete:   {"d"},
+	actionComplete: {"space"},
+	actionUndo:     {"u"},
+	actionLogout:   {"L"},
+	actionHelp:     {"?", "f1"},
+	actionSearch:   {"/"},
+
+	actionOpenBrowser: {"ctrl+o"},
+
+	actionNextPane:     {"tab"},
+	actionPreviousPane: {"shift+tab"},
+
+	actionBoard:          {"b"},
+	actionPreviousColumn: {"left"},
+	actionNextColumn:     {"right"},
+	actionMoveLeft:       {"shift+left", "<"},
+	actionMoveRight:      {"shift+right", ">"},
+
+	actionNextField:     {"down", "tab"},
+	actionPreviousField: {"up", "shift+tab"},
+	actionSave:          {"enter"},
+	actionCancel:        {"esc"},
+	actionConfirm:       {"enter"},
+
+	actionPreviousOption: {"left"},
+	actionNextOption:     {"right"},
+	actionToggle:         {"space", "left", "right"},
+	actionPreviousDay:    {"left"},
+	actionNextDay:        {"right"},
+	actionPreviousMonth:  {"pgup"},
+	actionNextMonth:      {"pgdown"},
+	actionEarlier:        {"-"},
+	actionLater:          {"+", "="},
+	actionToday:          {"t"},
+	actionClear:          {"delete", "backspace"},
+}
+
+// keyPresets replace some of the default keys, selected with tui.keymap.
+// Letters are only bound in views without text inputs.
+var keyPresets = map[string]map[action][]string{
+	"default": {},
+	"vim": {
+		actionUp:       {"k", "up"},
+		actionDown:     {"j", "down"},
+		actionTop:      {"g g", "home"},
+		actionBottom:   {"G", "end"},
+		actionPageUp:   {"ctrl+b", "pgup"},
+		actionPageDown: {"ctrl+f", "pgdown"},
+		actionDelete:   {"d d"},
+		actionComplete: {"x", "space"},
+
+		actionPreviousColumn: {"h", "left"},
+		actionNextColumn:     {"l", "right"},
+	},
+	"emacs": {
+		actionQuit:           {"ctrl+c", "ctrl+x ctrl+c"},
+		actionUp:             {"ctrl+p", "up"},
+		actionDown:           {"ctrl+n", "down"},
+		actionTop:            {"alt+<", "home"},
+		actionBottom:         {"alt+>", "end"},
+		actionPageUp:         {"alt+v", "pgup"},
+		actionPageDown:       {"ctrl+v", "pgdown"},
+		actionBack:           {"esc", "ctrl+g"},
+		actionNextField:      {"down", "tab", "ctrl+n"},
+		actionPreviousField:  {"up", "shift+tab", "ctrl+p"},
+		actionCancel:         {"esc", "ctrl+g"},
+		actionPreviousOption: {"left", "ctrl+b"},
+		actionNextOption:     {"right", "ctrl+f"},
+		actionPreviousDay:    {"left", "ctrl+b"},
+		actionNextDay:        {"right", "ctrl+f"},
+		actionPreviousColumn: {"left", "ctrl+b"},
+		actionNextColumn:     {"right", "ctrl+f"},
+	},
+}
+
+// keyMap holds the keys bound to each action
+type keyMap map[action]key.Binding
+
+// newKeyMap builds the keys of preset with the keys of some actions replaced
+// by overrides. Unknown presets and actions are ignored, they are reported
+// when the config is validated.
+func newKeyMap(preset string, overrides map[string][]string) keyMap {
+	keys := make(map[action][]string, len(defaultKeys))
+	for a, k := range defaultKeys {
+		keys[a] = k
+	}
+	for a, k := range keyPresets[preset] {
+		keys[a] = k
+	}
+	for name, k := range overrides {
+		if _, ok := keys[action(name)]; ok {
+			keys[action(name)] = k
+		}
+	}
+
+	km := make(keyMap, len(keys))
+	for a, k := range keys {
+		km[a] = key.NewBinding(key.WithKeys(k...))
+	}
+	return km
+}
+
+// keyHelp is an entry of the help bar: actions that are listed together and
+// what they do in the view
+type keyHelp struct {
+	actions []action
+	desc    string
+}
+
+// viewKeys lists the actions of each view in the order of the help bar.
+// Actions not listed for a view do nothing there.
+var viewKeys = map[models.ViewState][]keyHelp{
+	models.ConfigView: {
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionPreviousField, actionNextField}, "Select"},
+		{[]action{actionSave}, "Save"},
+	},
+	models.AuthView: {
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionOpenBrowser}, "Open in browser"},
+		{[]action{actionSave}, "Submit"},
+	},
+	models.ProjectListView: {
+		{[]action{actionUndo}, "Undo"},
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionUp, actionDown}, "Select"},
+		{[]action{actionTop, actionBottom}, "First/Last"},
+		{[]action{actionOpen}, "Open"},
+		{[]action{actionNextPane, actionPreviousPane}, "Pane"},
+		{[]action{actionNew}, "New"},
+		{[]action{actionDelete}, "Delete"},
+		{[]action{actionEdit}, "Edit"},
+		{[]action{actionSearch}, "Search"},
+		{[]action{actionLogout}, "Logout"},
+	},
+	models.TaskListView: {
+		{[]action{actionUndo}, "Undo"},
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionUp, actionDown}, "Select"},
+		{[]action{actionTop, actionBottom}, "First/Last"},
+		{[]action{actionOpen}, "Open"},
+		{[]action{actionNextPane, actionPreviousPane}, "Pane"},
+		{[]action{actionNew}, "New"},
+		{[]action{actionDelete}, "Delete"},
+		{[]action{actionEdit}, "Edit"},
+		{[]action{actionComplete}, "[Un]Complete"},
+		{[]action{actionBoard}, "Board"},
+		{[]action{actionSearch}, "Search"},
+		{[]action{actionBack}, "Back"},
+		{[]action{actionLogout}, "Logout"},
+	},
+	models.BoardView: {
+		{[]action{actionUndo}, "Undo"},
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionUp, actionDown}, "Select"},
+		{[]action{actionPreviousColumn, actionNextColumn}, "Column"},
+		{[]action{actionTop, actionBottom}, "First/Last"},
+		{[]action{actionOpen}, "Open"},
+		{[]action{actionMoveLeft, actionMoveRight}, "Move"},
+		{[]action{actionNew}, "New"},
+		{[]action{actionDelete}, "Delete"},
+		{[]action{actionEdit}, "Edit"},
+		{[]action{actionComplete}, "[Un]Complete"},
+		{[]action{actionBoard}, "List"},
+		{[]action{actionSearch}, "Search"},
+		{[]action{actionBack}, "Back"},
+		{[]action{actionLogout}, "Logout"},
+	},
+	models.TaskDetailView: {
+		{[]action{actionUndo}, "Undo"},
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionUp, actionDown}, "Scroll"},
+		{[]action{actionPageUp, actionPageDown}, "Page"},
+		{[]action{actionTop, actionBottom}, "Top/Bottom"},
+		{[]action{actionNextPane, actionPreviousPane}, "Pane"},
+		{[]action{actionEdit}, "Edit"},
+		{[]action{actionDelete}, "Delete"},
+		{[]action{actionComplete}, "[Un]Complete"},
+		{[]action{actionSearch}, "Search"},
+		{[]action{actionBack}, "Back"},
+	},
+	models.CreateTaskView:    formKeys,
+	models.CreateProjectView: formKeys,
+	models.DeleteConfirmView: {
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionConfirm}, "Confirm"},
+		{[]action{actionCancel}, "Back"},
+	},
+	models.SearchView: {
+		{[]action{actionQuit}, "Exit"},
+		{[]action{actionUp, actionDown}, "Select"},
+		{[]action{actionOpen}, "Go to task"},
+		{[]action{actionBack}, "Back"},
+	},
+}
+
+var formKeys = []keyHelp{
+	{[]action{actionQuit}, "Exit"},
+	{[]action{actionPreviousField, actionNextField}, "Field"},
+	{[]action{actionSave}, "Save"},
+	{[]action{actionCancel}, "Cancel"},
+}
+
+// fieldKeys lists the actions of a form field after those of the form
+func fieldKeys(field any) []keyHelp {
+	switch field.(type) {
+	case choiceField, colorField:
+		return []keyHelp{
+			{[]action{actionPreviousOption, actionNextOption}, "Change"},
+		}
+	case toggleField:
+		return []keyHelp{
+			{[]action{actionToggle}, "Toggle"},
+		}
+	case dateField:
+		return []keyHelp{
+			{[]action{actionPreviousDay, actionNextDay}, "Day"},
+			{[]action{actionPreviousMonth, actionNextMonth}, "Month"},
+			{[]action{actionEarlier, actionLater}, "Time"},
+			{[]action{actionToday}, "Today"},
+			{[]action{actionClear}, "Clear"},
+		}
+	}
+	return nil
+}
+
+// helpOverlayKeys are the actions of the help overlay, which is shown over
+// any view
+var helpOverlayKeys = []keyHelp{
+	{[]action{actionHelp, actionBack}, "Close"},
+	{[]action{actionQuit}, "Exit"},
+	{[]action{actionUp, actionDown}, "Scroll"},
+	{[]action{actionPageUp, actionPageDown}, "Page"},
+	{[]action{actionTop, actionBottom}, "Top/Bottom"},
+}
+
+// keyCategories group the actions in the help overlay, in this order
+var keyCategories = []struct {
+	name    string
+	actions []action
+}{
+	{"Navigation", []action{
+		actionUp, actionDown, actionTop, actionBottom, actionPageUp, actionPageDown,
+		actionOpen, actionBack, actionNextPane, actionPreviousPane,
+		actionPreviousColumn, actionNextColumn, actionBoard,
+		actionPreviousField, actionNextField, actionSearch,
+	}},
+	{"Editing", []action{
+		actionNew, actionEdit, actionDelete, actionComplete, actionUndo,
+		actionMoveLeft, actionMoveRight, actionSave, actionConfirm, actionCancel,
+	}},
+	{"Field", []action{
+		actionPreviousOption, actionNextOption, actionToggle, actionPreviousDay,
+		actionNextDay, actionPreviousMonth, actionNextMonth, actionEarlier,
+		actionLater, actionToday, actionClear,
+	}},
+	{"General", []action{actionHelp, actionOpenBrowser, actionLogout, actionQuit}},
+}
+
+// keyHelp returns the actions available right now
+func (m *Model) keyHelp() []keyHelp {
+	if m.showHelp {
+		return helpOverlayKeys
+	}
+	return m.viewKeyHelp()
+}
+
+// viewKeyHelp returns the actions of the current view
+func (m *Model) viewKeyHelp() []keyHelp {
+	help := keyHelp{[]action{actionHelp}, "Help"}
+	entries := viewKeys[m.state.CurrentView]
+	if len(entries) == 0 {
+		return []keyHelp{help, {[]action{actionQuit}, "Exit"}}
+	}
+	// Listed first so that it is not cut off on narrow terminals
+	entries = append([]keyHelp{help}, entries...)
+
+	switch m.state.CurrentView {
+	case models.CreateTaskView, models.CreateProjectView:
+		if m.state.SelectedIndex < len(m.state.CurrentItems) {
+			entries = append(slices.Clip(entries), fieldKeys(m.state.CurrentItems[m.state.SelectedIndex])...)
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(entries), func(h keyHelp) bool {
+		switch h.actions[0] {
+		case actionUndo:
+			return m.undoEntry == nil
+		case actionNextPane:
+			return !m.splitLayout()
+		}
+		return false
+	})
+}
+
+// helpBindings returns bindings for entries to show as help. They list the
+// first key of each action, or all keys when full is set. Entries without
+// keys are left out.
+func (m *Model) helpBindings(entries []keyHelp, full bool) []key.Binding {
+	// The full help describes the view below the overlay
+	typing := m.typing() && (full || !m.showHelp)
+
+	var bindings []key.Binding
+	for _, h := range entries {
+		var keys, labels []string
+		for _, a := range h.actions {
+			bound := m.boundKeys(a, typing)
+			if len(bound) == 0 {
+				continue
+			}
+			keys = append(keys, bound...)
+			if !full {
+				labels = append(labels, keyLabel(bound[0]))
+				continue
+			}
+			all := make([]string, len(bound))
+			for i, k := range bound {
+				all[i] = keyLabel(k)
+			}
+			labels = append(labels, strings.Join(all, "/"))
+		}
+		sep := "/"
+		if full {
+			sep = ", "
+		}
+		if len(keys) > 0 {
+			bindings = append(bindings, key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(labels, sep), h.desc)))
+		}
+	}
+	return bindings
+}
+
+// helpGroups returns the bindings of the current view by category
+func (m *Model) helpGroups() (names []string, groups [][]key.Binding) {
+	entries := m.viewKeyHelp()
+	for _, category := range keyCategories {
+		var inCategory []keyHelp
+		for _, h := range entries {
+			if slices.Contains(category.actions, h.actions[0]) {
+				inCategory = append(inCategory, h)
+			}
+		}
+		if bindings := m.helpBindings(inCategory, true); len(bindings) > 0 {
+			names = append(names, category.name)
+			groups = append(groups, bindings)
+		}
+	}
+	return names, groups
+}
+
+// matchKey returns the action the key is bound to in the current view, or
+// "" for none. Keys that start a sequence are held until it is complete.
+func (m *Model) matchKey(msg tea.KeyMsg) action {
+	name := msg.String()
+	if msg.Type == tea.KeySpace {
+		name = "space"
+	}
+	seq := name
+	if m.keyPrefix != "" {
+		seq = m.keyPrefix + " " + name
+	}
+	m.keyPrefix = ""
+
+	// Text typed into an input is not a key
+	typing := !m.showHelp && m.typing()
+
+	var prefix bool
+	for _, h := range m.keyHelp() {
+		for _, a := range h.actions {
+			for _, k := range m.boundKeys(a, typing) {
+				if k == seq {
+					return a
+				}
+				prefix = prefix || strings.HasPrefix(k, seq+" ")
+			}
+		}
+	}
+	if prefix {
+		m.keyPrefix = seq
+		return ""
+	}
+	if seq != name {
+		// The sequence broke off, the key may start another one
+		return m.matchKey(msg)
+	}
+	return ""
+}
+
+// boundKeys returns the keys of an action, leaving out those that type text
+// when typing into an input
+func (m *Model) boundKeys(a action, typing bool) []string {
+	keys := m.keys[a].Keys()
+	if !typing {
+		return keys
+	}
+	return slices.DeleteFunc(slices.Clone(keys), func(k string) bool {
+		first, _, _ := strings.Cut(k, " ")
+		return first == "space" || utf8.RuneCountInString(first) == 1
+	})
+}
+
+// typing reports whether keys go to a text input
+func (m *Model) typing() bool {
+	if m.state.CurrentView == models.SearchView {
+		return true
+	}
+	if i := m.state.SelectedIndex; i < len(m.state.CurrentItems) {
+		_, ok := m.state.CurrentItems[i].(textinput.Model)
+		return ok
+	}
+	return false
+}
+
+// keyName names the first key bound to a, for messages that mention it
+func (m *Model) keyName(a action) string {
+	if keys := m.keys[a].Keys(); len(keys) > 0 {
+		return keyLabel(keys[0])
+	}
+	return string(a)
+}
+
+// keyLabel formats a key for the help bar, e.g. Ctrl+c or gg
+func keyLabel(k string) string {
+	switch k {
+	case "pgup":
+		return "PgUp"
+	case "pgdown":
+		return "PgDn"
+	case "delete":
+		return "Del"
+	}
+	if strings.Contains(k, " ") {
+		// Sequences of letters are written together, like in vim
+		parts := strings.Fields(k)
+		sep := ""
+		for i, part := range parts {
+			if len(part) > 1 {
+				sep = " "
+			}
+			parts[i] = keyLabel(part)
+		}
+		return strings.Join(parts, sep)
+	}
+	if len(k) > 1 {
+		return strings.ToUpper(k[:1]) + k[1:]
+	}
+	return k
+}
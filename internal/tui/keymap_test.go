@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"testing"
+	"ticktick-tui/internal/models"
+	"ticktick-tui/internal/trash"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMsgs maps key names to the messages bubbletea sends for them
+var keyMsgs = map[string]tea.KeyMsg{
+	"space":  {Type: tea.KeySpace, Runes: []rune{' '}},
+	"enter":  {Type: tea.KeyEnter},
+	"esc":    {Type: tea.KeyEsc},
+	"down":   {Type: tea.KeyDown},
+	"ctrl+c": {Type: tea.KeyCtrlC},
+	"ctrl+x": {Type: tea.KeyCtrlX},
+	"alt+<":  {Type: tea.KeyRunes, Runes: []rune{'<'}, Alt: true},
+}
+
+func keyMsg(name string) tea.KeyMsg {
+	if msg, ok := keyMsgs[name]; ok {
+		return msg
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		view      models.ViewState
+		undo      bool
+		keys      []string
+		want      []action
+	}{
+		{"single key", "default", nil, models.TaskListView, false, []string{"d", "space"}, []action{actionDelete, actionComplete}},
+		{"action of another view", "default", nil, models.ProjectListView, false, []string{"b"}, []action{""}},
+		{"undo without entry", "default", nil, models.TaskListView, false, []string{"u"}, []action{""}},
+		{"undo with entry", "default", nil, models.TaskListView, true, []string{"u"}, []action{actionUndo}},
+		{"sequence", "vim", nil, models.TaskListView, false, []string{"g", "g", "d", "d"}, []action{"", actionTop, "", actionDelete}},
+		{"broken sequence", "vim", nil, models.TaskListView, false, []string{"g", "j"}, []action{"", actionDown}},
+		{"broken sequence starts another", "vim", nil, models.TaskListView, false, []string{"d", "g", "g"}, []action{"", "", actionTop}},
+		{"shifted letter", "vim", nil, models.TaskListView, false, []string{"G"}, []action{actionBottom}},
+		{"modifier sequence", "emacs", nil, models.ProjectListView, false, []string{"ctrl+x", "ctrl+c"}, []action{"", actionQuit}},
+		{"alt key", "emacs", nil, models.ProjectListView, false, []string{"alt+<"}, []action{actionTop}},
+		{"override", "default", map[string][]string{"undo": {"z"}}, models.TaskListView, true, []string{"u", "z"}, []action{"", actionUndo}},
+		{"unknown override", "default", map[string][]string{"nope": {"d"}}, models.TaskListView, false, []string{"d"}, []action{actionDelete}},
+		{"typing skips letters", "vim", nil, models.SearchView, false, []string{"j", "down", "enter"}, []action{"", actionDown, actionOpen}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Model{
+				keys:  newKeyMap(tt.preset, tt.overrides),
+				state: &models.AppState{CurrentView: tt.view},
+			}
+			if tt.undo {
+				m.undoEntry = &trash.Entry{}
+			}
+			for i, k := range tt.keys {
+				if got := m.matchKey(keyMsg(k)); got != tt.want[i] {
+					t.Errorf("key %d (%s) = %q, want %q", i, k, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		preset    string
+		overrides map[string][]string
+		action    action
+		want      string
+	}{
+		{"default", nil, actionUndo, "u"},
+		{"default", nil, actionLogout, "L"},
+		{"default", map[string][]string{"undo": {"ctrl+z", "z"}}, actionUndo, "Ctrl+z"},
+		{"default", map[string][]string{"logout": {"Q"}}, actionLogout, "Q"},
+		{"vim", nil, actionTop, "gg"},
+		{"emacs", nil, actionQuit, "Ctrl+c"},
+		{"default", map[string][]string{"undo": {}}, actionUndo, "undo"},
+	}
+	for _, tt := range tests {
+		m := &Model{keys: newKeyMap(tt.preset, tt.overrides)}
+		if got := m.keyName(tt.action); got != tt.want {
+			t.Errorf("%s %v: keyName(%s) = %q, want %q", tt.preset, tt.overrides, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestKeyLabel(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"u", "u"},
+		{"enter", "Enter"},
+		{"ctrl+x ctrl+c", "Ctrl+x Ctrl+c"},
+		{"g g", "gg"},
+		{"pgdown", "PgDn"},
+		{"delete", "Del"},
+	}
+	for _, tt := range tests {
+		if got := keyLabel(tt.key); got != tt.want {
+			t.Errorf("keyLabel(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
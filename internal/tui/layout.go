@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"slices"
+	"ticktick-tui/internal/models"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Terminals at least this wide show the projects, the tasks and the selected
+// task side by side. Narrower ones show one of them at a time.
+const splitLayoutWidth = 120
+
+// How long the selection has to rest on a project before its tasks are
+// loaded into the task pane
+const previewDelay = 200 * time.Millisecond
+
+// splitLayout reports whether the current view is shown as a pane of the
+// split layout
+func (m *Model) splitLayout() bool {
+	if m.width < splitLayoutWidth {
+		return false
+	}
+	if n := len(m.history); n > 0 && m.history[n-1].view == models.BoardView {
+		// Tasks opened from the board are shown on their own
+		return false
+	}
+	switch m.state.CurrentView {
+	case models.ProjectListView, models.TaskListView, models.TaskDetailView:
+		return true
+	}
+	return false
+}
+
+// panes are the views of the split layout in focus order
+var panes = []models.ViewState{models.ProjectListView, models.TaskListView, models.TaskDetailView}
+
+// cyclePane moves the focus to the next or previous pane
+func (m *Model) cyclePane(direction int) tea.Cmd {
+	if !m.splitLayout() {
+		return nil
+	}
+	i := slices.Index(panes, m.state.CurrentView)
+	for range panes {
+		i = (i + direction + len(panes)) % len(panes)
+		if m.canFocus(panes[i]) {
+			return m.focusPane(panes[i])
+		}
+	}
+	return nil
+}
+
+// canFocus reports whether a pane has something to focus
+func (m *Model) canFocus(view models.ViewState) bool {
+	switch view {
+	case models.TaskListView:
+		return m.state.CurrentProject != nil
+	case models.TaskDetailView:
+		return m.selectedTask() != nil
+	}
+	return true
+}
+
+// focusPane moves the focus to the pane of view without reloading it. The
+// navigation stack is set up as if the view had been opened with Enter, so
+// Esc and the breadcrumbs work as in the single-pane layout.
+func (m *Model) focusPane(view models.ViewState) tea.Cmd {
+	projectIndex := max(m.projectIndex(), 0)
+	switch view {
+	case models.ProjectListView:
+		m.history = nil
+		m.showProjectList(projectIndex)
+	case models.TaskListView:
+		taskIndex := 0
+		if task := m.selectedTask(); task != nil {
+			taskIndex = max(m.taskIndex(task.ID), 0)
+		}
+		m.history = []navEntry{{view: models.ProjectListView, selected: projectIndex}}
+		m.showTaskList(taskIndex)
+	case models.TaskDetailView:
+		task := m.selectedTask()
+		if task == nil {
+			return nil
+		}
+		m.state.CurrentTask = task
+		m.history = []navEntry{
+			{view: models.ProjectListView, selected: projectIndex},
+			{view: models.TaskListView, selected: m.taskIndex(task.ID)},
+		}
+		return m.changeView(models.TaskDetailView)
+	}
+	return nil
+}
+
+// selectedTask returns the task shown in the detail pane: the one selected
+// in the task list, or the open one
+func (m *Model) selectedTask() *models.Task {
+	switch m.state.CurrentView {
+	case models.TaskListView:
+		if m.state.SelectedIndex < len(m.state.Tasks) {
+			task := m.state.Tasks[m.state.SelectedIndex]
+			return &task
+		}
+		return nil
+	case models.ProjectListView:
+		return nil
+	}
+	return m.state.CurrentTask
+}
+
+// projectIndex returns the index of the current project, or -1
+func (m *Model) projectIndex() int {
+	if m.state.CurrentProject == nil {
+		return -1
+	}
+	id := m.state.CurrentProject.ID
+	return slices.IndexFunc(m.state.Projects, func(p models.Project) bool { return p.ID == id })
+}
+
+// taskIndex returns the index of a task of the current project, or -1
+func (m *Model) taskIndex(id string) int {
+	return slices.IndexFunc(m.state.Tasks, func(t models.Task) bool { return t.ID == id })
+}
+
+// previewProject shows the tasks of the selected project in the task pane.
+// Tasks seen before are shown right away, and reloaded once the selection
+// has rested for a moment.
+func (m *Model) previewProject() tea.Cmd {
+	if !m.splitLayout() || m.state.CurrentView != models.ProjectListView || m.state.SelectedIndex >= len(m.state.Projects) {
+		return nil
+	}
+	project := &m.state.Projects[m.state.SelectedIndex]
+	cached, ok := m.taskCache[project.ID]
+	if m.state.CurrentProject != nil && m.state.CurrentProject.ID == project.ID && ok {
+		return nil
+	}
+
+	m.state.CurrentProject = project
+	m.state.CurrentTask = nil
+	m.state.Tasks = cached
+	m.previewID++
+	id := m.previewID
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewTickMsg{id: id}
+	})
+}
+
+// renderSplit shows the projects, the tasks of the current project and the
+// selected task side by side, framing the pane that has the focus
+func (m *Model) renderSplit() string {
+	height := max(m.height-4, 3) - paneStyle.GetVerticalFrameSize()
+	projectsWidth := m.width / 4
+	tasksWidth := m.width * 3 / 8
+	detailWidth := m.width - projectsWidth - tasksWidth
+
+	pane := func(view models.ViewState, width int, content string) string {
+		style := paneStyle
+		if view == m.state.CurrentView {
+			style = paneFocusedStyle
+		}
+		width -= style.GetHorizontalFrameSize()
+		return style.Width(width).Height(height).MaxHeight(height + style.GetVerticalFrameSize()).Render(content)
+	}
+	listHeight := height - listStyle.GetVerticalFrameSize()
+
+	projectIndex := m.state.SelectedIndex
+	if m.state.CurrentView != models.ProjectListView {
+		projectIndex = m.projectIndex()
+	}
+	projects := m.projectList(projectsWidth-paneStyle.GetHorizontalFrameSize(), listHeight, projectIndex)
+
+	// No task is highlighted while the projects have the focus
+	taskIndex := -1
+	switch m.state.CurrentView {
+	case models.TaskListView:
+		taskIndex = m.state.SelectedIndex
+	case models.TaskDetailView:
+		if m.state.CurrentTask != nil {
+			taskIndex = m.taskIndex(m.state.CurrentTask.ID)
+		}
+	}
+	tasks := m.taskList(tasksWidth-paneStyle.GetHorizontalFrameSize(), listHeight, taskIndex)
+
+	width := detailWidth - paneStyle.GetHorizontalFrameSize() - detailStyle.GetHorizontalPadding()
+	var detail string
+	switch task := m.selectedTask(); {
+	case m.state.CurrentView == models.TaskDetailView && task != nil:
+		m.detail.Width = width
+		m.detail.Height = height - detailStyle.GetVerticalPadding()
+		m.detail.SetContent(taskDetail(*task, width))
+		detail = detailStyle.Render(m.detail.View())
+	case task != nil:
+		detail = detailStyle.Render(lipgloss.NewStyle().
+			MaxHeight(height - detailStyle.GetVerticalPadding()).
+			Render(taskDetail(*task, width)))
+	default:
+		detail = detailStyle.Foreground(DARK_GRAY).Render("Select a task to see it here")
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		pane(models.ProjectListView, projectsWidth, projects),
+		pane(models.TaskListView, tasksWidth, tasks),
+		pane(models.TaskDetailView, detailWidth, detail),
+	)
+}
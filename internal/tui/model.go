@@ -1,15 +1,22 @@
 package tui
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"ticktick-tui/internal/client"
+	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/models"
+	"ticktick-tui/internal/trash"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/spf13/viper"
 	"golang.org/x/term"
 )
 
@@ -27,17 +34,141 @@ type Model struct {
 
 	// Auth Inputs
 	authInputs []textinput.Model
+
+	// Scrolls the task detail
+	detail viewport.Model
+
+	// Views to return to with Esc
+	history []navEntry
+
+	// Bound keys and the start of a key sequence typed so far, e.g. g of gg
+	keys      keyMap
+	keyPrefix string
+
+	// Search prompt, the tasks of all projects it searches and the tasks
+	// matching the query
+	searchInput   textinput.Model
+	searchQuery   string
+	searchTasks   []models.Task
+	searchResults []searchResult
+
+	// Tasks of the projects seen in the split layout, by project ID
+	taskCache map[string][]models.Task
+	// Incremented to cancel loading the tasks of a project passed over
+	previewID int
+
+	// Columns of the current project's board, ordered
+	columns []models.Column
+	// Selected column and the first column shown on the board
+	boardColumn int
+	boardOffset int
+
+	// The help overlay listing all keys of the current view is open
+	showHelp bool
+	help     help.Model
+	helpView viewport.Model
+
+	// Task or project edited in the forms, nil when creating one
+	editTask    *models.Task
+	editProject *models.Project
+	// Validation errors of the form fields by index
+	formErrors map[int]string
+	// Numbers the placeholders of tasks that are being created
+	pendingTasks int
+
+	// Stops the loopback listener waiting for the OAuth redirect
+	cancelAuthCallback context.CancelFunc
+
+	// Logout was requested once and waits for confirmation
+	confirmLogout bool
+
+	// Deletion waiting for confirmation in DeleteConfirmView
+	deleting *deletion
+	// Last deleted entry, which can be restored while undoLeft seconds remain
+	undoEntry *trash.Entry
+	undoLeft  int
+	undoID    int
+
+	// Changes of the config file on disk, waiting to be reloaded
+	configReloads chan struct{}
+	// Tokens renewed by the client, waiting to be saved
+	tokenSaves chan *models.OAuthToken
+	// Incremented to cancel pending refresh ticks when the interval changes
+	refreshGen int
+
+	// Last error written to the log
+	loggedError string
+
+	// Short-lived status bar message
+	notice      string
+	noticeError bool
+	noticeID    int
 }
 
 type (
 	projectsLoadedMsg []models.Project
-	tasksLoadedMsg    []models.Task
+	tasksLoadedMsg    struct {
+		projectID string
+		tasks     []models.Task
+		columns   []models.Column
+	}
+	// Tasks by project ID, and the names of projects that failed to load
+	searchTasksLoadedMsg struct {
+		tasks  map[string][]models.Task
+		failed []string
+	}
+	previewTickMsg struct{ id int }
 
-	taskCreatedMsg    *models.Task
-	projectCreatedMsg *models.Project
+	projectSavedMsg *models.Project
+	taskUpdatedMsg  *models.Task
+
+	deleteCountedMsg struct {
+		projectID string
+		count     int
+	}
+	deletedMsg  struct{ entry *trash.Entry }
+	restoredMsg struct{ entry *trash.Entry }
+	undoTickMsg struct{ id int }
+
+	// pendingID identifies the placeholder inserted while the task is created
+	taskCreatedMsg struct {
+		pendingID string
+		task      *models.Task
+	}
+	taskCreateFailedMsg struct {
+		pendingID string
+		err       error
+	}
+	taskSavedMsg      *models.Task
+	taskSaveFailedMsg struct {
+		original models.Task
+		err      error
+	}
+	taskMovedMsg struct {
+		task   *models.Task
+		column string
+	}
+	// The board column the task was moved from is selected again
+	taskMoveFailedMsg struct {
+		original models.Task
+		column   int
+		err      error
+	}
 
 	configSavedMsg    struct{}
-	tokenExchangedMsg struct{}
+	tokenExchangedMsg struct{ token *models.OAuthToken }
+	tokenRefreshedMsg struct{ token *models.OAuthToken }
+	loggedOutMsg      struct{}
+
+	authCodeReceivedMsg   struct{ code, state string }
+	authCallbackFailedMsg struct{ err error }
+
+	// The API rejected the access token and it could not be refreshed
+	unauthorizedMsg struct{}
+
+	configChangedMsg struct{}
+	refreshTickMsg   struct{ gen int }
+	clearNoticeMsg   struct{ id int }
 )
 
 func NewModel() *Model {
@@ -52,12 +183,17 @@ func NewModel() *Model {
 
 	clientSecretInput := textinput.New()
 	clientSecretInput.Placeholder = "Client Secret"
+	clientSecretInput.EchoMode = textinput.EchoPassword
 
 	redirectURIInput := textinput.New()
 	redirectURIInput.Placeholder = "Redirect URI"
 
 	authCodeInput := textinput.New()
-	authCodeInput.Placeholder = "Authorization Code"
+	authCodeInput.Placeholder = "Redirect URL"
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "/ "
+	searchInput.Placeholder = "Search tasks"
 
 	// Initialize model
 	m := &Model{
@@ -77,6 +213,13 @@ func NewModel() *Model {
 		authInputs: []textinput.Model{
 			authCodeInput,
 		},
+		detail:        viewport.New(0, 0),
+		searchInput:   searchInput,
+		taskCache:     map[string][]models.Task{},
+		help:          help.New(),
+		helpView:      viewport.New(0, 0),
+		configReloads: make(chan struct{}, 1),
+		tokenSaves:    make(chan *models.OAuthToken, 1),
 	}
 
 	return m
@@ -88,17 +231,33 @@ func (m *Model) Init() tea.Cmd {
 	// Set window title
 	cmds = append(cmds, tea.SetWindowTitle("ticktick-tui"))
 
+	m.applyTheme()
+	m.applyKeys()
+	core.WatchConfig(func() {
+		// A reload is already pending
+		select {
+		case m.configReloads <- struct{}{}:
+		default:
+		}
+	})
+	cmds = append(cmds, m.waitForConfigReload(), m.waitForTokenSave(), m.scheduleRefresh())
+
 	// Set initial view
 	// Check config and set initial view
-	if viper.GetString("client_id") == "" || viper.GetString("client_secret") == "" || viper.GetString("redirect_uri") == "" {
-		m.configInputs[0].SetValue(viper.GetString("client_id"))
-		m.configInputs[1].SetValue(viper.GetString("client_secret"))
-		m.configInputs[2].SetValue(viper.GetString("redirect_uri"))
+	profile := core.CurrentProfile()
+	clientSecret, _ := core.GetSecret("client_secret")
+	if profile.ClientID == "" || clientSecret == "" || profile.RedirectURI == "" {
+		m.configInputs[0].SetValue(profile.ClientID)
+		m.configInputs[1].SetValue(clientSecret)
+		m.configInputs[2].SetValue(profile.RedirectURI)
 		cmds = append(cmds, m.changeView(models.ConfigView))
-	} else if viper.GetString("access_token") == "" {
+	} else if info := core.GetTokenInfo(); !info.HasToken {
 		cmds = append(cmds, m.changeView(models.AuthView))
+	} else if info.Expired() && !info.HasRefreshToken {
+		cmds = append(cmds, m.changeView(models.AuthView))
+		m.state.Error = "访问令牌已过期，请重新授权"
 	} else { // Load projects
-		m.client = client.NewClient(viper.GetString("access_token"))
+		m.client = m.newClient()
 		cmds = append(cmds, m.changeView(models.ProjectListView))
 	}
 
@@ -109,13 +268,17 @@ func (m *Model) Init() tea.Cmd {
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	m.traceMsg(msg)
+	defer m.logError()
+
 	// Message handling
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Force a re-render by returning the model
-		return m, nil
+		m.scrollBoard()
+		// Fill the task pane when the terminal became wide enough
+		return m, m.previewProject()
 
 	case tea.KeyMsg:
 		// Handle manual resize trigger for Windows
@@ -126,49 +289,238 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if err == nil {
 				m.width = width
 				m.height = height
+				m.scrollBoard()
 			}
 			return m, tea.Sequence(tea.ClearScreen, tea.EnterAltScreen)
 		}
 		// Typical key handling
-		cmds = append(cmds, m.handleKey(msg.String()))
+		overlay := m.showHelp
+		cmds = append(cmds, m.handleKey(msg))
+		if overlay || m.showHelp {
+			// Keys of the help overlay are not typed into the inputs below
+			return m, tea.Batch(cmds...)
+		}
 
 	case projectsLoadedMsg:
 		m.state.Error = ""   // Clear any previous error
 		m.state.Message = "" // Clear any previous message
 		m.state.Projects = []models.Project(msg)
+		m.clampSelection(len(m.state.Projects))
 		items := make([]any, len(m.state.Projects))
 		for i, project := range m.state.Projects {
 			items[i] = project
 		}
 		m.state.CurrentItems = items
+		cmds = append(cmds, m.previewProject())
 
 	case tasksLoadedMsg:
 		m.state.Error = ""   // Clear any previous error
 		m.state.Message = "" // Clear any previous message
-		m.state.Tasks = []models.Task(msg)
-		items := make([]any, len(m.state.Tasks))
-		for i, task := range m.state.Tasks {
-			items[i] = task
+		m.taskCache[msg.projectID] = msg.tasks
+		if m.state.CurrentProject == nil || m.state.CurrentProject.ID != msg.projectID {
+			// The project was passed over in the meantime
+			break
 		}
-		m.state.CurrentItems = items
+		m.state.Tasks = msg.tasks
+		m.setColumns(msg.columns)
+		switch m.state.CurrentView {
+		case models.TaskListView:
+			m.clampSelection(len(m.state.Tasks))
+			items := make([]any, len(m.state.Tasks))
+			for i, task := range m.state.Tasks {
+				items[i] = task
+			}
+			m.state.CurrentItems = items
+		case models.BoardView:
+			m.showBoard(m.state.SelectedIndex)
+		}
+
+	case previewTickMsg:
+		if msg.id == m.previewID {
+			return m, m.loadTasks()
+		}
+		return m, nil
+
+	case searchTasksLoadedMsg:
+		for id, tasks := range msg.tasks {
+			m.taskCache[id] = tasks
+		}
+		tasks, _ := m.cachedSearchTasks()
+		m.searchTasks = append([]models.Task{}, tasks...)
+		if m.state.CurrentView == models.SearchView {
+			m.updateSearch()
+		}
+		if len(msg.failed) > 0 {
+			slices.Sort(msg.failed)
+			return m, m.showNotice(fmt.Sprintf("%d 个项目加载失败，搜索时已跳过：%s", len(msg.failed), strings.Join(msg.failed, "、")), true)
+		}
+
+	case taskCreatedMsg:
+		m.replacePendingTask(msg.pendingID, *msg.task)
+		return m, m.showNotice("任务已创建", false)
+
+	case taskCreateFailedMsg:
+		m.replacePendingTask(msg.pendingID, models.Task{})
+		m.state.Error = "创建任务失败：" + msg.err.Error()
 
-	// case taskCreatedMsg:
-	// 	m.state.Message = "任务创建成功"
-	// 	m.state.CurrentView = models.TaskListView
-	// 	return m, m.loadTasks(m.state.CurrentProject.ID)
-	// case projectCreatedMsg:
-	// 	m.state.Message = "项目创建成功"
-	// 	m.state.CurrentView = models.ProjectListView
-	// 	return m, m.loadProjects()
+	case taskSavedMsg:
+		m.putTask(*msg)
+		return m, m.showNotice("任务已保存", false)
+
+	case taskSaveFailedMsg:
+		m.putTask(msg.original)
+		m.state.Error = "保存任务失败：" + msg.err.Error()
+
+	case taskMoveFailedMsg:
+		// Follow the task back unless another task was selected meanwhile
+		selected := m.state.CurrentView == models.BoardView && m.boardTask() != nil && m.boardTask().ID == msg.original.ID
+		if selected && msg.column < len(m.boardColumns()) {
+			m.boardColumn = msg.column
+		}
+		m.putTask(msg.original)
+		if selected {
+			m.showBoard(slices.IndexFunc(m.columnTasks(m.boardColumn), func(t models.Task) bool { return t.ID == msg.original.ID }))
+		}
+		m.state.Error = "移动任务失败：" + msg.err.Error()
+
+	case taskMovedMsg:
+		m.putTask(*msg.task)
+		return m, m.showNotice("任务已移到 "+msg.column, false)
+
+	case projectSavedMsg:
+		project := *msg
+		index := slices.IndexFunc(m.state.Projects, func(p models.Project) bool { return p.ID == project.ID })
+		if index < 0 {
+			m.state.Projects = append(m.state.Projects, project)
+			index = len(m.state.Projects) - 1
+		}
+		m.state.Projects[index] = project
+		if m.state.CurrentView == models.ProjectListView {
+			m.showProjectList(index)
+		}
+		return m, m.showNotice("项目已保存", false)
+
+	case taskUpdatedMsg:
+		m.putTask(*msg)
+		notice := "任务已重新打开"
+		if msg.Status == models.StatusCompleted {
+			notice = "任务已完成"
+		}
+		if m.state.CurrentView == models.TaskDetailView {
+			return m, m.showNotice(notice, false)
+		}
+		// Completed tasks drop out of the list
+		return m, tea.Batch(m.loadTasks(), m.showNotice(notice, false))
+
+	case deleteCountedMsg:
+		if d := m.deleting; d != nil && d.project != nil && d.project.ID == msg.projectID {
+			d.taskCount = msg.count
+		}
+
+	case deletedMsg:
+		switch msg.entry.Kind {
+		case trash.KindTask:
+			id := msg.entry.Task.ID
+			m.state.Tasks = slices.DeleteFunc(m.state.Tasks, func(t models.Task) bool { return t.ID == id })
+			if m.state.CurrentTask != nil && m.state.CurrentTask.ID == id {
+				m.state.CurrentTask = nil
+			}
+			switch m.state.CurrentView {
+			case models.TaskListView:
+				m.showTaskList(m.state.SelectedIndex)
+			case models.BoardView:
+				m.showBoard(m.state.SelectedIndex)
+			}
+		case trash.KindProject:
+			id := msg.entry.Project.Project.ID
+			m.state.Projects = slices.DeleteFunc(m.state.Projects, func(p models.Project) bool { return p.ID == id })
+			if m.state.CurrentProject != nil && m.state.CurrentProject.ID == id {
+				m.state.CurrentProject = nil
+			}
+			if m.state.CurrentView == models.ProjectListView {
+				m.showProjectList(m.state.SelectedIndex)
+			}
+		}
+		return m, m.startUndo(msg.entry)
+
+	case undoTickMsg:
+		if msg.id != m.undoID || m.undoEntry == nil {
+			return m, nil
+		}
+		if m.undoLeft--; m.undoLeft <= 0 {
+			m.undoEntry = nil
+			return m, nil
+		}
+		return m, m.undoTick()
+
+	case restoredMsg:
+		return m, tea.Batch(m.refreshList(), m.showNotice("已恢复 "+msg.entry.Title(), false))
 
 	case configSavedMsg:
 		m.state.Message = "配置已保存！"
 		return m, m.changeView(models.AuthView)
 
+	case authCodeReceivedMsg:
+		m.cancelAuthCallback = nil
+		return m, m.exchangeAuthCode(msg.code, msg.state)
+
+	case authCallbackFailedMsg:
+		m.cancelAuthCallback = nil
+		if m.state.CurrentView == models.AuthView {
+			m.state.Message = "自动授权未完成，请手动粘贴跳转后的完整URL：" + msg.err.Error()
+		}
+
 	case tokenExchangedMsg:
+		m.stopAuthCallback()
+		if err := core.FinishLogin(msg.token); err != nil {
+			m.state.Error = "保存访问令牌失败：" + err.Error()
+			return m, nil
+		}
 		m.state.Message = "认证成功！"
-		m.client = client.NewClient(viper.GetString("access_token"))
+		m.client = m.newClient()
 		return m, m.changeView(models.ProjectListView)
+
+	case tokenRefreshedMsg:
+		if err := core.SaveToken(msg.token); err != nil {
+			m.state.Error = "保存刷新后的访问令牌失败：" + err.Error()
+		}
+		return m, m.waitForTokenSave()
+
+	case configChangedMsg:
+		cmds := []tea.Cmd{m.waitForConfigReload()}
+		// Reloaded here, while no command reads the configuration
+		if err := core.ReloadConfig(); err != nil {
+			m.state.Error = "配置文件无效，未应用修改：" + err.Error()
+			cmds = append(cmds, m.showNotice("配置重新加载失败", true))
+		} else {
+			cmds = append(cmds, m.applyConfig(), m.showNotice("配置已重新加载", false))
+		}
+		return m, tea.Batch(cmds...)
+
+	case refreshTickMsg:
+		if msg.gen != m.refreshGen {
+			return m, nil
+		}
+		return m, tea.Batch(m.refreshList(), m.scheduleRefresh())
+
+	case clearNoticeMsg:
+		if msg.id == m.noticeID {
+			m.notice = ""
+		}
+		return m, nil
+
+	case loggedOutMsg:
+		m.client = nil
+		m.state.Projects = []models.Project{}
+		m.state.Tasks = []models.Task{}
+		cmd := m.changeView(models.AuthView)
+		m.state.Message = "已退出配置档案 " + core.ActiveProfile()
+		return m, cmd
+
+	case unauthorizedMsg:
+		cmd := m.changeView(models.AuthView)
+		m.state.Error = "访问令牌已失效或已过期，请重新授权"
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
@@ -177,6 +529,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.spinner, cmd = m.spinner.Update(msg)
 	cmds = append(cmds, cmd)
 
+	if m.state.CurrentView == models.SearchView {
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		cmds = append(cmds, cmd)
+		if m.searchInput.Value() != m.searchQuery {
+			m.updateSearch()
+		}
+	}
+
 	// Update items
 	for idx := range m.state.CurrentItems {
 		switch v := m.state.CurrentItems[idx].(type) {
@@ -211,6 +572,24 @@ func (m *Model) View() string {
 		content = m.renderProjectList()
 	case models.TaskListView:
 		content = m.renderTaskList()
+	case models.TaskDetailView:
+		content = m.renderTaskDetail()
+	case models.CreateTaskView:
+		content = m.renderTaskForm()
+	case models.CreateProjectView:
+		content = m.renderProjectForm()
+	case models.DeleteConfirmView:
+		content = m.renderDeleteConfirm()
+	case models.SearchView:
+		content = m.renderSearch()
+	case models.BoardView:
+		content = m.renderBoard()
+	}
+	if m.splitLayout() {
+		content = m.renderSplit()
+	}
+	if m.showHelp {
+		content = m.renderHelpOverlay()
 	}
 
 	message := m.renderMessage()
@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"ticktick-tui/internal/models"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// Fields of the project form, in the order of CurrentItems
+const (
+	projectFieldName = iota
+	projectFieldColor
+	projectFieldViewMode
+	projectFieldKind
+	projectFieldGroup
+)
+
+var projectFieldLabels = []string{"Name", "Color", "View", "Kind", "Group"}
+
+// Colors offered by the TickTick apps
+var projectColors = []string{
+	"#F18181",
+	"#F2B04B",
+	"#FFD866",
+	"#5CD0A7",
+	"#9BECEC",
+	"#4AA6EF",
+	"#4772FA",
+	"#CF66F7",
+	"#A5A6AA",
+}
+
+var (
+	projectViewModes = []string{"list", "kanban", "timeline"}
+	projectKinds     = []string{"TASK", "NOTE"}
+)
+
+// colorField picks one of the project colors, shown as swatches
+type colorField struct {
+	choiceField
+}
+
+// newProjectForm returns the fields of the project form, filled from project
+// when editing
+func newProjectForm(project *models.Project, projects []models.Project) []any {
+	if project == nil {
+		project = &models.Project{ViewMode: "list", Kind: "TASK"}
+	}
+
+	name := textinput.New()
+	name.Placeholder = "Name"
+	name.SetValue(project.Name)
+
+	color := colorField{choiceField{labels: []string{"None"}, values: []string{""}}}
+	for _, c := range projectColors {
+		color.labels = append(color.labels, c)
+		color.values = append(color.values, c)
+	}
+	if !selectValue(&color.choiceField, project.Color) {
+		color.labels = append(color.labels, project.Color)
+		color.values = append(color.values, project.Color)
+		color.selected = len(color.values) - 1
+	}
+
+	viewMode := choiceField{labels: projectViewModes, values: projectViewModes}
+	selectValue(&viewMode, project.ViewMode)
+
+	kind := choiceField{labels: projectKinds, values: projectKinds}
+	selectValue(&kind, strings.ToUpper(project.Kind))
+
+	// Groups cannot be created through the API, only existing ones are offered
+	group := choiceField{labels: []string{"None"}, values: []string{""}}
+	for _, p := range projects {
+		if p.GroupID != "" && !slices.Contains(group.values, p.GroupID) {
+			group.labels = append(group.labels, "Group "+shortID(p.GroupID))
+			group.values = append(group.values, p.GroupID)
+		}
+	}
+	selectValue(&group, project.GroupID)
+
+	return []any{name, color, viewMode, kind, group}
+}
+
+// selectValue selects the option with value, reporting whether there is one
+func selectValue(f *choiceField, value string) bool {
+	for i, v := range f.values {
+		if v == value {
+			f.selected = i
+			return true
+		}
+	}
+	return false
+}
+
+// shortID returns the end of an ID, which is enough to tell them apart
+func shortID(id string) string {
+	if len(id) > 4 {
+		return id[len(id)-4:]
+	}
+	return id
+}
+
+// projectFromForm builds the project described by the form on top of base,
+// returning the validation errors by field
+func projectFromForm(items []any, base models.Project) (*models.Project, map[int]string) {
+	errs := map[int]string{}
+	project := base
+
+	project.Name = strings.TrimSpace(items[projectFieldName].(textinput.Model).Value())
+	if project.Name == "" {
+		errs[projectFieldName] = "名称不能为空"
+	}
+	project.Color = items[projectFieldColor].(colorField).value()
+	project.ViewMode = items[projectFieldViewMode].(choiceField).value()
+	project.Kind = items[projectFieldKind].(choiceField).value()
+	project.GroupID = items[projectFieldGroup].(choiceField).value()
+
+	return &project, errs
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"ticktick-tui/internal/models"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// searchResult is a task matching the search, with the text it matched in
+type searchResult struct {
+	task    models.Task
+	project string
+	// field is the matched text when it is not the title, e.g. a tag
+	field string
+	// matches are the indexes of the matched runes in the title or field
+	matches []int
+}
+
+func (r searchResult) FilterValue() string { return r.task.Title }
+
+// searchField is a piece of text of a task that is searched
+type searchField struct {
+	task  int
+	title bool
+	// Shown before the text in the results, e.g. # for tags
+	prefix string
+	text   string
+}
+
+type searchFields []searchField
+
+func (f searchFields) String(i int) string { return f[i].text }
+func (f searchFields) Len() int            { return len(f) }
+
+// How many characters of a long field are shown before the first match
+const searchContext = 10
+
+// searchTasks ranks the tasks whose title, content, checklist items or tags
+// fuzzy match query, best first
+func searchTasks(query string, tasks []models.Task, projects []models.Project) []searchResult {
+	var fields searchFields
+	for i, task := range tasks {
+		fields = append(fields, searchField{task: i, title: true, text: task.Title})
+		for _, line := range strings.Split(task.Content+"\n"+task.Desc, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fields = append(fields, searchField{task: i, text: line})
+			}
+		}
+		for _, item := range task.Items {
+			box := "☐ "
+			if item.Status == models.ItemStatusCompleted {
+				box = "☑ "
+			}
+			fields = append(fields, searchField{task: i, prefix: box, text: item.Title})
+		}
+		for _, tag := range task.Tags {
+			fields = append(fields, searchField{task: i, prefix: "#", text: tag})
+		}
+	}
+
+	names := make(map[string]string, len(projects))
+	for _, project := range projects {
+		names[project.ID] = project.Name
+	}
+
+	// Matches are sorted by score, so the first one of a task is its best
+	var results []searchResult
+	found := make(map[int]bool)
+	for _, match := range fuzzy.FindFrom(query, fields) {
+		field := fields[match.Index]
+		if found[field.task] {
+			continue
+		}
+		found[field.task] = true
+
+		task := tasks[field.task]
+		result := searchResult{task: task, project: names[task.ProjectID]}
+		matches := runeIndexes(field.text, match.MatchedIndexes)
+		if field.title {
+			result.matches = matches
+		} else {
+			text := field.text
+			// Start long text shortly before the match
+			if start := matches[0] - searchContext; start > 0 {
+				text = "…" + string([]rune(text)[start:])
+				for i := range matches {
+					matches[i] -= start - 1
+				}
+			}
+			offset := utf8.RuneCountInString(field.prefix)
+			for i := range matches {
+				matches[i] += offset
+			}
+			result.field = field.prefix + text
+			result.matches = matches
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runeIndexes converts the byte offsets fuzzy reports to rune indexes
+func runeIndexes(s string, offsets []int) []int {
+	indexes := make([]int, len(offsets))
+	for i, offset := range offsets {
+		indexes[i] = utf8.RuneCountInString(s[:offset])
+	}
+	return indexes
+}
+
+// searchDelegate draws search results like the default list delegate, with
+// the matched characters highlighted
+type searchDelegate struct{}
+
+func (d searchDelegate) Height() int                         { return 2 }
+func (d searchDelegate) Spacing() int                        { return 1 }
+func (d searchDelegate) Update(tea.Msg, *list.Model) tea.Cmd { return nil }
+
+func (d searchDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	r, ok := item.(searchResult)
+	if !ok {
+		return
+	}
+
+	titleStyle, descStyle := listNormalTitleStyle, listNormalDescStyle
+	if index == m.Index() {
+		titleStyle, descStyle = listSelectedTitleStyle, listSelectedDescStyle
+	}
+	width := m.Width() - titleStyle.GetHorizontalFrameSize()
+
+	title := truncate(r.task.Title, width)
+	desc := truncate(r.project, width)
+	if r.field == "" {
+		title = highlight(title, r.matches, titleStyle)
+	} else {
+		// The field is shown after the project
+		prefix := r.project + " • "
+		offset := utf8.RuneCountInString(prefix)
+		matches := make([]int, len(r.matches))
+		for i, match := range r.matches {
+			matches[i] = match + offset
+		}
+		desc = highlight(truncate(prefix+r.field, width), matches, descStyle)
+	}
+
+	fmt.Fprintf(w, "%s\n%s", titleStyle.Render(title), descStyle.Render(desc))
+}
+
+// highlight styles the runes of s at matches, for rendering with style
+func highlight(s string, matches []int, style lipgloss.Style) string {
+	unmatched := style.Copy().Inline(true)
+	return lipgloss.StyleRunes(s, matches, searchMatchStyle.Copy().Inherit(unmatched), unmatched)
+}
@@ -4,7 +4,9 @@ import "github.com/charmbracelet/lipgloss"
 
 type Color = lipgloss.Color
 
-const (
+// The palette can be changed through tui.colors in the config file, so
+// these are variables; defaultPalette keeps the original values.
+var (
 	BLACK          Color = lipgloss.Color("0")  // Terminal color index 0 (black)
 	RED            Color = lipgloss.Color("1")  // Terminal color index 1 (red)
 	GREEN          Color = lipgloss.Color("2")  // Terminal color index 2 (green)
@@ -23,121 +25,304 @@ const (
 	WHITE          Color = lipgloss.Color("15") // Terminal color index 15 (white)
 )
 
+// palette maps the names used in tui.colors to the palette variables
+var palette = map[string]*Color{
+	"black":          &BLACK,
+	"red":            &RED,
+	"green":          &GREEN,
+	"yellow":         &YELLOW,
+	"blue":           &BLUE,
+	"magenta":        &MAGENTA,
+	"cyan":           &CYAN,
+	"light_gray":     &LIGHT_GRAY,
+	"dark_gray":      &DARK_GRAY,
+	"bright_red":     &BRIGHT_RED,
+	"bright_green":   &BRIGHT_GREEN,
+	"bright_yellow":  &BRIGHT_YELLOW,
+	"bright_blue":    &BRIGHT_BLUE,
+	"bright_magenta": &BRIGHT_MAGENTA,
+	"bright_cyan":    &BRIGHT_CYAN,
+	"white":          &WHITE,
+}
+
+var defaultPalette = map[string]Color{}
+
+func init() {
+	for name, color := range palette {
+		defaultPalette[name] = *color
+	}
+	buildStyles()
+}
+
+// applyTheme resets the palette, replaces the given colors and rebuilds all
+// styles
+func applyTheme(colors map[string]string) {
+	for name, color := range palette {
+		*color = defaultPalette[name]
+		if override, ok := colors[name]; ok {
+			*color = Color(override)
+		}
+	}
+	buildStyles()
+}
+
 var (
+	statusBarStyle,
+	statusLeftStyle,
+	statusModeStyle,
+	statusMessageStyle,
+	statusErrorStyle,
+	statusRightStyle,
+	statusProfileStyle,
+	statusBreadcrumbStyle,
+	spinnerStyle,
+	formStyle,
+	formTitleStyle,
+	formFocusedStyle,
+	formBlurredStyle,
+	formFocusedPlaceHolderStyle,
+	formBlurredPlaceHolderStyle,
+	formLabelStyle,
+	formErrorStyle,
+	calendarStyle,
+	calendarTodayStyle,
+	authURLStyle,
+	listStyle,
+	listNormalTitleStyle,
+	listNormalDescStyle,
+	listSelectedTitleStyle,
+	listSelectedDescStyle,
+	detailStyle,
+	detailTitleStyle,
+	detailLabelStyle,
+	detailDoneStyle,
+	dialogStyle,
+	dialogTitleStyle,
+	messageStyle,
+	messageErrorStyle,
+	helpStyle,
+	helpKeyStyle,
+	helpDescStyle,
+	helpCategoryStyle,
+	helpOverlayKeyStyle,
+	helpOverlayDescStyle,
+	searchMatchStyle,
+	paneStyle,
+	paneFocusedStyle,
+	boardHeaderStyle,
+	boardCountStyle lipgloss.Style
+
+	paginatorActive,
+	paginatorInactive,
+	priorityNone,
+	priorityLow,
+	priorityMedium,
+	priorityHigh string
+)
+
+// buildStyles derives all styles from the current palette
+func buildStyles() {
 	// Status bar styles
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(WHITE).
-			Height(1)
+		Foreground(WHITE).
+		Height(1)
 
 	statusLeftStyle = lipgloss.NewStyle().
-			Bold(true).
-			Padding(0, 1)
+		Bold(true).
+		Padding(0, 1)
 
 	statusModeStyle = lipgloss.NewStyle().
-			Background(GREEN).
-			Foreground(BLACK).
-			Bold(true).
-			Padding(0, 1)
+		Background(GREEN).
+		Foreground(BLACK).
+		Bold(true).
+		Padding(0, 1)
 
 	statusMessageStyle = lipgloss.NewStyle().
-				Foreground(WHITE).
-				Padding(0, 1)
+		Foreground(WHITE).
+		Padding(0, 1)
 
 	statusErrorStyle = lipgloss.NewStyle().
-				Background(RED).
-				Foreground(WHITE).
-				Bold(true).
-				Padding(0, 1)
+		Background(RED).
+		Foreground(WHITE).
+		Bold(true).
+		Padding(0, 1)
 
 	statusRightStyle = lipgloss.NewStyle().
-				Background(LIGHT_GRAY).
-				Foreground(BLACK).
-				Padding(0, 1)
+		Background(LIGHT_GRAY).
+		Foreground(BLACK).
+		Padding(0, 1)
+
+	statusProfileStyle = lipgloss.NewStyle().
+		Background(MAGENTA).
+		Foreground(BLACK).
+		Bold(true).
+		Padding(0, 1)
+
+	statusBreadcrumbStyle = lipgloss.NewStyle().
+		Foreground(BRIGHT_BLUE).
+		Padding(0, 1)
 
 	// Spinner style
 	spinnerStyle = lipgloss.NewStyle().
-			Foreground(CYAN)
+		Foreground(CYAN)
 
 	// Form styles
 	formStyle = lipgloss.NewStyle().
-			Padding(2, 4)
+		Padding(2, 4)
 
 	formTitleStyle = lipgloss.NewStyle().
-			Foreground(BRIGHT_BLUE).
-			Bold(true).
-			Margin(0, 0, 1, 0)
+		Foreground(BRIGHT_BLUE).
+		Bold(true).
+		Margin(0, 0, 1, 0)
 
 	formFocusedStyle = lipgloss.NewStyle().
-				Background(LIGHT_GRAY).
-				Foreground(BLACK)
+		Background(LIGHT_GRAY).
+		Foreground(BLACK)
 
 	formBlurredStyle = lipgloss.NewStyle().
-				Foreground(DARK_GRAY)
+		Foreground(DARK_GRAY)
 
 	formFocusedPlaceHolderStyle = lipgloss.NewStyle().
-					Background(LIGHT_GRAY).
-					Foreground(BRIGHT_BLUE)
+		Background(LIGHT_GRAY).
+		Foreground(BRIGHT_BLUE)
 
 	formBlurredPlaceHolderStyle = lipgloss.NewStyle().
-					Foreground(DARK_GRAY)
+		Foreground(DARK_GRAY)
+
+	formLabelStyle = lipgloss.NewStyle().
+		Foreground(DARK_GRAY).
+		Width(12)
+
+	formErrorStyle = lipgloss.NewStyle().
+		Foreground(BRIGHT_RED).
+		PaddingLeft(12)
+
+	calendarStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(DARK_GRAY).
+		Padding(0, 1).
+		MarginLeft(4)
+
+	calendarTodayStyle = lipgloss.NewStyle().
+		Foreground(BRIGHT_BLUE).
+		Underline(true)
 
 	authURLStyle = lipgloss.NewStyle().
-			Foreground(CYAN).
-			Margin(1, 0)
+		Foreground(CYAN).
+		Margin(1, 0)
 
 	// List styles
 	listStyle = lipgloss.NewStyle().
-			Padding(1, 0)
+		Padding(1, 0)
 
 	listNormalTitleStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(WHITE)).
-				Padding(0, 0, 0, 2)
+		Foreground(lipgloss.Color(WHITE)).
+		Padding(0, 0, 0, 2)
 
 	listNormalDescStyle = listNormalTitleStyle.Copy().
-				Foreground(lipgloss.Color(DARK_GRAY))
+		Foreground(lipgloss.Color(DARK_GRAY))
 
 	listSelectedTitleStyle = lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder(), false, false, false, true).
-				BorderForeground(lipgloss.Color(BRIGHT_BLUE)).
-				Foreground(lipgloss.Color(BRIGHT_BLUE)).
-				Padding(0, 0, 0, 1)
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color(BRIGHT_BLUE)).
+		Foreground(lipgloss.Color(BRIGHT_BLUE)).
+		Padding(0, 0, 0, 1)
 
 	listSelectedDescStyle = listSelectedTitleStyle.Copy().
-				Foreground(lipgloss.Color(BLUE))
+		Foreground(lipgloss.Color(BLUE))
+
+	searchMatchStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(BRIGHT_YELLOW)).
+		Underline(true)
+
+	// Panes of the split layout
+	paneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(DARK_GRAY))
+
+	paneFocusedStyle = paneStyle.Copy().
+		BorderForeground(lipgloss.Color(BRIGHT_BLUE))
+
+	// Kanban board column headers
+	boardHeaderStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(BRIGHT_BLUE)).
+		Bold(true).
+		Padding(0, 1)
+
+	boardCountStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(DARK_GRAY))
+
+	// Task detail styles
+	detailStyle = lipgloss.NewStyle().
+		Padding(1, 2)
+
+	detailTitleStyle = lipgloss.NewStyle().
+		Foreground(BRIGHT_BLUE).
+		Bold(true)
+
+	detailLabelStyle = lipgloss.NewStyle().
+		Foreground(DARK_GRAY).
+		Width(12)
+
+	detailDoneStyle = lipgloss.NewStyle().
+		Foreground(DARK_GRAY).
+		Strikethrough(true)
+
+	// Dialog styles
+	dialogStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(RED).
+		Padding(1, 3)
+
+	dialogTitleStyle = lipgloss.NewStyle().
+		Foreground(BRIGHT_RED).
+		Bold(true).
+		Margin(0, 0, 1, 0)
 
 	// Message styles
 	messageStyle = lipgloss.NewStyle().
-			Foreground(GREEN).
-			Bold(true).
-			Padding(0, 1).
-			Margin(0, 0, 1, 0)
+		Foreground(GREEN).
+		Bold(true).
+		Padding(0, 1).
+		Margin(0, 0, 1, 0)
 
 	messageErrorStyle = lipgloss.NewStyle().
-				Foreground(BRIGHT_RED).
-				Bold(true).
-				Padding(0, 1).
-				Margin(0, 0, 1, 0)
+		Foreground(BRIGHT_RED).
+		Bold(true).
+		Padding(0, 1).
+		Margin(0, 0, 1, 0)
 
 	// Help styles
 	helpStyle = lipgloss.NewStyle().
-			Foreground(DARK_GRAY).
-			Padding(0, 1).
-			Height(1)
+		Foreground(DARK_GRAY).
+		Padding(0, 1).
+		Height(1)
 
 	helpKeyStyle = lipgloss.NewStyle().
-			Foreground(DARK_GRAY).
-			Bold(true)
+		Foreground(DARK_GRAY).
+		Bold(true)
 
 	helpDescStyle = lipgloss.NewStyle().
-			Foreground(DARK_GRAY)
+		Foreground(DARK_GRAY)
+
+	helpCategoryStyle = lipgloss.NewStyle().
+		Foreground(BLUE).
+		Bold(true)
+
+	helpOverlayKeyStyle = lipgloss.NewStyle().
+		Foreground(WHITE).
+		Bold(true)
+
+	helpOverlayDescStyle = lipgloss.NewStyle().
+		Foreground(LIGHT_GRAY)
 
 	// Paginator styles
-	paginatorActive   = lipgloss.NewStyle().Foreground(lipgloss.Color(WHITE)).Render("◈ ")
+	paginatorActive = lipgloss.NewStyle().Foreground(lipgloss.Color(WHITE)).Render("◈ ")
 	paginatorInactive = lipgloss.NewStyle().Foreground(lipgloss.Color(DARK_GRAY)).Render("◇ ")
 
 	// Priority styles
-	priorityNone   = lipgloss.NewStyle().Foreground(LIGHT_GRAY).Render("None")
-	priorityLow    = lipgloss.NewStyle().Foreground(BRIGHT_BLUE).Render("Low")
+	priorityNone = lipgloss.NewStyle().Foreground(LIGHT_GRAY).Render("None")
+	priorityLow = lipgloss.NewStyle().Foreground(BRIGHT_BLUE).Render("Low")
 	priorityMedium = lipgloss.NewStyle().Foreground(BRIGHT_YELLOW).Render("Medium")
-	priorityHigh   = lipgloss.NewStyle().Foreground(BRIGHT_RED).Render("High")
-)
+	priorityHigh = lipgloss.NewStyle().Foreground(BRIGHT_RED).Render("High")
+}
@@ -0,0 +1,390 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"ticktick-tui/internal/models"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// Fields of the task form, in the order of CurrentItems
+const (
+	taskFieldTitle = iota
+	taskFieldContent
+	taskFieldProject
+	taskFieldPriority
+	taskFieldAllDay
+	taskFieldStart
+	taskFieldDue
+	taskFieldReminders
+	taskFieldRepeat
+)
+
+var taskFieldLabels = []string{"Title", "Content", "Project", "Priority", "All day", "Start", "Due", "Reminders", "Repeat"}
+
+// choiceField selects one of a fixed list of options with left/right
+type choiceField struct {
+	labels   []string
+	values   []string
+	selected int
+	// The value cannot be changed, e.g. the project of an existing task
+	locked bool
+}
+
+// handleKey changes the option, reporting false for actions it does not use
+func (f *choiceField) handleKey(a action) bool {
+	direction := 0
+	switch a {
+	case actionPreviousOption:
+		direction = -1
+	case actionNextOption:
+		direction = 1
+	default:
+		return false
+	}
+	if !f.locked && len(f.labels) > 0 {
+		f.selected = (f.selected + direction + len(f.labels)) % len(f.labels)
+	}
+	return true
+}
+
+func (f choiceField) value() string {
+	if f.selected < len(f.values) {
+		return f.values[f.selected]
+	}
+	return ""
+}
+
+func (f choiceField) label() string {
+	if f.selected < len(f.labels) {
+		return f.labels[f.selected]
+	}
+	return ""
+}
+
+// toggleField is switched on and off
+type toggleField struct {
+	on bool
+}
+
+// dateField picks a date and time with the keyboard. It starts out empty.
+type dateField struct {
+	value time.Time
+	set   bool
+	loc   *time.Location
+}
+
+// How far +/- move the time of a date field
+const dateFieldStep = 15 * time.Minute
+
+// Time of day given to a date when it is first picked
+const defaultTaskHour = 9
+
+// handleKey changes the date, reporting false for actions it does not use
+func (f *dateField) handleKey(a action) bool {
+	if !f.set {
+		switch a {
+		case actionPreviousDay, actionNextDay, actionPreviousMonth, actionNextMonth, actionEarlier, actionLater, actionToday:
+			now := time.Now().In(f.loc)
+			f.value = time.Date(now.Year(), now.Month(), now.Day(), defaultTaskHour, 0, 0, 0, f.loc)
+			f.set = true
+			if a == actionToday {
+				return true
+			}
+		}
+	}
+
+	switch a {
+	case actionPreviousDay:
+		f.value = f.value.AddDate(0, 0, -1)
+	case actionNextDay:
+		f.value = f.value.AddDate(0, 0, 1)
+	case actionPreviousMonth:
+		f.value = f.value.AddDate(0, -1, 0)
+	case actionNextMonth:
+		f.value = f.value.AddDate(0, 1, 0)
+	case actionEarlier:
+		f.value = f.value.Add(-dateFieldStep)
+	case actionLater:
+		f.value = f.value.Add(dateFieldStep)
+	case actionToday:
+		now := time.Now().In(f.loc)
+		f.value = time.Date(now.Year(), now.Month(), now.Day(), f.value.Hour(), f.value.Minute(), 0, 0, f.loc)
+	case actionClear:
+		f.set = false
+	default:
+		return false
+	}
+	return true
+}
+
+// date returns the picked date for the API, at midnight for all-day tasks
+func (f dateField) date(allDay bool) *models.TickTickTime {
+	if !f.set {
+		return nil
+	}
+	t := f.value
+	if allDay {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, f.loc)
+	}
+	// TickTickTime is written with a literal +0000 offset
+	return &models.TickTickTime{Time: t.UTC()}
+}
+
+// Repeat rules offered by the form
+var repeatOptions = []struct{ label, flag string }{
+	{"None", ""},
+	{"Daily", "RRULE:FREQ=DAILY;INTERVAL=1"},
+	{"Weekdays", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TU,WE,TH,FR"},
+	{"Weekly", "RRULE:FREQ=WEEKLY;INTERVAL=1"},
+	{"Monthly", "RRULE:FREQ=MONTHLY;INTERVAL=1"},
+	{"Yearly", "RRULE:FREQ=YEARLY;INTERVAL=1"},
+}
+
+var taskPriorities = []models.TaskPriority{
+	models.PriorityNone,
+	models.PriorityLow,
+	models.PriorityMedium,
+	models.PriorityHigh,
+}
+
+// newTaskForm returns the fields of the task form, filled from task when
+// editing. projectID preselects the project of a new task.
+func newTaskForm(task *models.Task, projects []models.Project, projectID string) []any {
+	if task == nil {
+		task = &models.Task{ProjectID: projectID}
+	}
+
+	title := textinput.New()
+	title.Placeholder = "Title"
+	title.SetValue(task.Title)
+
+	content := textinput.New()
+	content.Placeholder = "Notes"
+	content.SetValue(task.Content)
+
+	project := choiceField{locked: task.ID != ""}
+	for _, p := range projects {
+		if p.Closed && p.ID != task.ProjectID {
+			continue
+		}
+		if p.ID == task.ProjectID {
+			project.selected = len(project.values)
+		}
+		project.labels = append(project.labels, p.Name)
+		project.values = append(project.values, p.ID)
+	}
+
+	priority := choiceField{}
+	for i, p := range taskPriorities {
+		priority.labels = append(priority.labels, p.String())
+		priority.values = append(priority.values, strconv.Itoa(int(p)))
+		if p == task.Priority {
+			priority.selected = i
+		}
+	}
+
+	loc := time.Local
+	if task.TimeZone != "" {
+		if l, err := time.LoadLocation(task.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	start, due := dateField{loc: loc}, dateField{loc: loc}
+	if task.StartDate != nil {
+		start.value, start.set = task.StartDate.Time.In(loc), true
+	}
+	if task.DueDate != nil {
+		due.value, due.set = task.DueDate.Time.In(loc), true
+	}
+
+	reminders := textinput.New()
+	reminders.Placeholder = "e.g. 0, 15m, 1d (before due)"
+	values := make([]string, len(task.Reminders))
+	for i, reminder := range task.Reminders {
+		values[i] = reminderInput(reminder)
+	}
+	reminders.SetValue(strings.Join(values, ", "))
+
+	repeat := choiceField{}
+	for _, option := range repeatOptions {
+		repeat.labels = append(repeat.labels, option.label)
+		repeat.values = append(repeat.values, option.flag)
+		if option.flag == task.RepeatFlag {
+			repeat.selected = len(repeat.values) - 1
+		}
+	}
+	if task.RepeatFlag != "" && repeat.selected == 0 {
+		// Keep a rule the form cannot express
+		repeat.labels = append(repeat.labels, formatRepeat(task.RepeatFlag))
+		repeat.values = append(repeat.values, task.RepeatFlag)
+		repeat.selected = len(repeat.values) - 1
+	}
+
+	return []any{
+		title,
+		content,
+		project,
+		priority,
+		toggleField{on: task.IsAllDay},
+		start,
+		due,
+		reminders,
+		repeat,
+	}
+}
+
+// taskFromForm builds the task described by the form on top of base,
+// returning the validation errors by field
+func taskFromForm(items []any, base models.Task) (*models.Task, map[int]string) {
+	errs := map[int]string{}
+	task := base
+
+	task.Title = strings.TrimSpace(items[taskFieldTitle].(textinput.Model).Value())
+	if task.Title == "" {
+		errs[taskFieldTitle] = "标题不能为空"
+	}
+	task.Content = items[taskFieldContent].(textinput.Model).Value()
+
+	task.ProjectID = items[taskFieldProject].(choiceField).value()
+	if task.ProjectID == "" {
+		errs[taskFieldProject] = "请先创建一个项目"
+	}
+
+	priority, _ := strconv.Atoi(items[taskFieldPriority].(choiceField).value())
+	task.Priority = models.TaskPriority(priority)
+
+	task.IsAllDay = items[taskFieldAllDay].(toggleField).on
+	start, due := items[taskFieldStart].(dateField), items[taskFieldDue].(dateField)
+	task.StartDate = start.date(task.IsAllDay)
+	task.DueDate = due.date(task.IsAllDay)
+	if task.StartDate != nil && task.DueDate != nil && task.StartDate.After(task.DueDate.Time) {
+		errs[taskFieldDue] = "截止时间不能早于开始时间"
+	}
+
+	task.Reminders = nil
+	for _, value := range strings.Split(items[taskFieldReminders].(textinput.Model).Value(), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		trigger, err := parseReminder(value)
+		if err != nil {
+			errs[taskFieldReminders] = err.Error()
+			break
+		}
+		task.Reminders = append(task.Reminders, trigger)
+	}
+	if len(task.Reminders) > 0 && task.DueDate == nil && task.StartDate == nil {
+		errs[taskFieldReminders] = "设置提醒需要开始或截止时间"
+	}
+
+	task.RepeatFlag = items[taskFieldRepeat].(choiceField).value()
+	if task.RepeatFlag != "" && task.DueDate == nil && task.StartDate == nil {
+		errs[taskFieldRepeat] = "设置重复需要开始或截止时间"
+	}
+
+	return &task, errs
+}
+
+// reminderOffset returns the offset of a reminder trigger such as
+// TRIGGER:-PT15M from the due time
+func reminderOffset(reminder string) (time.Duration, bool) {
+	match := isoDuration.FindStringSubmatch(strings.TrimPrefix(reminder, "TRIGGER:"))
+	if match == nil {
+		return 0, false
+	}
+
+	var offset time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		n, _ := strconv.Atoi(match[i+2])
+		offset += time.Duration(n) * unit
+	}
+	if match[1] == "-" {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// reminderDuration matches the reminders typed into the form, e.g. 1d2h
+var reminderDuration = regexp.MustCompile(`^(\+)?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?$`)
+
+// parseReminder turns a typed reminder into a trigger. Reminders are before
+// the due time unless prefixed with +, and TRIGGER: values are kept as is.
+func parseReminder(value string) (string, error) {
+	if strings.HasPrefix(value, "TRIGGER:") {
+		if _, ok := reminderOffset(value); ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("无法识别的提醒：%s", value)
+	}
+	if value == "0" {
+		return "TRIGGER:PT0S", nil
+	}
+
+	match := reminderDuration.FindStringSubmatch(value)
+	if match == nil || value == "+" {
+		return "", fmt.Errorf("无法识别的提醒：%s（例如 15m、1h、1d2h）", value)
+	}
+
+	var offset time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+		n, _ := strconv.Atoi(match[i+2])
+		offset += time.Duration(n) * unit
+	}
+	if match[1] != "+" {
+		offset = -offset
+	}
+	return reminderTrigger(offset), nil
+}
+
+// reminderTrigger formats an offset from the due time as a trigger
+func reminderTrigger(offset time.Duration) string {
+	if offset == 0 {
+		return "TRIGGER:PT0S"
+	}
+
+	sign := ""
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	days := offset / (24 * time.Hour)
+	offset -= days * 24 * time.Hour
+
+	trigger := "TRIGGER:" + sign + "P"
+	if days > 0 {
+		trigger += fmt.Sprintf("%dD", days)
+	}
+	if offset > 0 {
+		trigger += "T"
+		if h := offset / time.Hour; h > 0 {
+			trigger += fmt.Sprintf("%dH", h)
+		}
+		if m := offset % time.Hour / time.Minute; m > 0 {
+			trigger += fmt.Sprintf("%dM", m)
+		}
+	}
+	return trigger
+}
+
+// reminderInput formats a trigger the way reminders are typed into the form
+func reminderInput(reminder string) string {
+	offset, ok := reminderOffset(reminder)
+	if !ok || offset%time.Minute != 0 {
+		return reminder
+	}
+	if offset == 0 {
+		return "0"
+	}
+
+	prefix := ""
+	if offset > 0 {
+		prefix = "+"
+	} else {
+		offset = -offset
+	}
+	return prefix + strings.ReplaceAll(formatDuration(offset), " ", "")
+}
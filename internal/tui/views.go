@@ -2,8 +2,13 @@ package tui
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
+	"ticktick-tui/internal/core"
 	"ticktick-tui/internal/models"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/paginator"
@@ -12,7 +17,6 @@ import (
 )
 
 func (m *Model) renderStatusBar() string {
-	// TODO: ADD project title to status bar in TaskListView
 	if m.width == 0 {
 		return ""
 	}
@@ -59,6 +63,39 @@ func (m *Model) renderStatusBar() string {
 			Width(leftSectionWidth).
 			Render("TASK")
 		mode = statusModeStyle.Width(modeWidth).Render("NORMAL")
+	case models.DeleteConfirmView:
+		leftSection = statusLeftStyle.
+			Foreground(BLACK).
+			Background(RED).
+			Width(leftSectionWidth).
+			Render("DELETE")
+		mode = statusModeStyle.Width(modeWidth).Render("NORMAL")
+	case models.BoardView:
+		leftSection = statusLeftStyle.
+			Foreground(BLACK).
+			Background(BLUE).
+			Width(leftSectionWidth).
+			Render("BOARD")
+		mode = statusModeStyle.Width(modeWidth).Render("NORMAL")
+	case models.SearchView:
+		leftSection = statusLeftStyle.
+			Foreground(BLACK).
+			Background(YELLOW).
+			Width(leftSectionWidth).
+			Render("SEARCH")
+		mode = statusModeStyle.Width(modeWidth).Render("INPUT")
+	case models.CreateTaskView, models.CreateProjectView:
+		name := "NEW"
+		if m.editTask != nil && m.state.CurrentView == models.CreateTaskView ||
+			m.editProject != nil && m.state.CurrentView == models.CreateProjectView {
+			name = "EDIT"
+		}
+		leftSection = statusLeftStyle.
+			Foreground(BLACK).
+			Background(GREEN).
+			Width(leftSectionWidth).
+			Render(name)
+		mode = statusModeStyle.Width(modeWidth).Render("INPUT")
 	default:
 		leftSection = statusLeftStyle.
 			Foreground(BLACK).
@@ -68,9 +105,20 @@ func (m *Model) renderStatusBar() string {
 		mode = statusModeStyle.Width(modeWidth).Render("NORMAL")
 	}
 
+	// Where we are, e.g. Projects › Work › Task
+	if crumbs := m.breadcrumbs(); len(crumbs) > 1 {
+		mode += statusBreadcrumbStyle.Render(strings.Join(crumbs, " › "))
+	}
+
 	// Middle section: Messages or errors
 	var middleSection string
-	if m.state.Error != "" {
+	if m.undoEntry != nil {
+		middleSection = statusMessageStyle.Render(fmt.Sprintf("已删除 %s，按 %s 撤销 (%ds)", m.undoEntry.Title(), m.keyName(actionUndo), m.undoLeft))
+	} else if m.notice != "" && m.noticeError {
+		middleSection = statusErrorStyle.Render(m.notice)
+	} else if m.notice != "" {
+		middleSection = statusMessageStyle.Render(m.notice)
+	} else if m.state.Error != "" {
 		middleSection = statusErrorStyle.Render("ERROR")
 	} else if m.state.Loading {
 		middleSection = statusMessageStyle.Render(fmt.Sprintf(" %s Loading... ", m.spinner.View()))
@@ -87,9 +135,25 @@ func (m *Model) renderStatusBar() string {
 		if len(m.state.Tasks) > 0 {
 			rightSection = statusRightStyle.Render(fmt.Sprintf("%d/%d", m.state.SelectedIndex+1, len(m.state.Tasks)))
 		}
+	case models.BoardView:
+		if n := len(m.state.CurrentItems); n > 0 {
+			rightSection = statusRightStyle.Render(fmt.Sprintf("%d/%d", m.state.SelectedIndex+1, n))
+		}
+	case models.SearchView:
+		if len(m.searchResults) > 0 {
+			rightSection = statusRightStyle.Render(fmt.Sprintf("%d/%d", m.state.SelectedIndex+1, len(m.searchResults)))
+		}
+	case models.TaskDetailView:
+		if percent := m.detail.ScrollPercent(); !math.IsNaN(percent) {
+			rightSection = statusRightStyle.Render(fmt.Sprintf("%3.f%%", percent*100))
+		}
 	case models.ConfigView:
 		rightSection = statusRightStyle.Render(fmt.Sprintf("Field %d/3", m.state.SelectedIndex+1))
+	case models.CreateTaskView, models.CreateProjectView:
+		rightSection = statusRightStyle.Render(fmt.Sprintf("Field %d/%d", m.state.SelectedIndex+1, len(m.state.CurrentItems)))
 	}
+	// Active profile, so it is clear which account changes go to
+	rightSection = statusProfileStyle.Render(core.ActiveProfile()) + rightSection
 
 	// Calculate available space for middle section
 	leftWidth := lipgloss.Width(leftSection + mode)
@@ -120,6 +184,71 @@ func (m *Model) renderStatusBar() string {
 	return statusBarStyle.Width(m.width).Render(statusContent)
 }
 
+// Longest breadcrumb before it is shortened
+const breadcrumbWidth = 20
+
+// breadcrumbs names the views on the navigation stack and the current view
+func (m *Model) breadcrumbs() []string {
+	views := make([]models.ViewState, 0, len(m.history)+1)
+	for _, entry := range m.history {
+		views = append(views, entry.view)
+	}
+	views = append(views, m.state.CurrentView)
+
+	var crumbs []string
+	for _, view := range views {
+		var crumb string
+		switch view {
+		case models.ProjectListView:
+			crumb = "Projects"
+		case models.TaskListView, models.BoardView:
+			if m.state.CurrentProject != nil {
+				crumb = m.state.CurrentProject.Name
+			}
+		case models.TaskDetailView:
+			if m.state.CurrentTask != nil {
+				crumb = m.state.CurrentTask.Title
+			}
+		case models.CreateTaskView:
+			crumb = "New task"
+			if m.editTask != nil {
+				crumb = "Edit"
+			}
+		case models.CreateProjectView:
+			crumb = "New project"
+			if m.editProject != nil {
+				crumb = "Edit"
+			}
+		case models.DeleteConfirmView:
+			crumb = "Delete"
+		case models.SearchView:
+			crumb = "Search"
+		}
+		if crumb != "" {
+			crumbs = append(crumbs, truncate(crumb, breadcrumbWidth))
+		}
+	}
+	if m.showHelp {
+		crumbs = append(crumbs, "Help")
+	}
+	return crumbs
+}
+
+// truncate shortens s to width cells, ending it with … if cut
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if lipgloss.Width(b.String()+string(r)) > width-1 {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + "…"
+}
+
 func (m *Model) renderConfigForm() string {
 	title := formTitleStyle.Render("TickTick 配置")
 
@@ -162,10 +291,15 @@ func (m *Model) renderAuthForm() string {
 	if m.state.AuthURL != "" {
 		form.WriteString(formBlurredStyle.Render("请在浏览器中打开以下链接进行授权:"))
 		form.WriteString("\n")
-		form.WriteString(formBlurredStyle.Render("已复制到剪贴板"))
+		form.WriteString(formBlurredStyle.Render(fmt.Sprintf("按 %s 在浏览器中打开并复制链接", m.keyName(actionOpenBrowser))))
 		form.WriteString("\n")
 		form.WriteString(authURLStyle.Width(m.width - 16).Render(m.state.AuthURL))
-		form.WriteString("\n\n")
+		form.WriteString("\n")
+		if m.cancelAuthCallback != nil {
+			form.WriteString(formBlurredStyle.Render("授权完成后将自动登录，也可以手动粘贴跳转后的完整URL"))
+			form.WriteString("\n")
+		}
+		form.WriteString("\n")
 	}
 
 	// Auth code input
@@ -198,9 +332,15 @@ func (m *Model) renderAuthForm() string {
 }
 
 func (m *Model) renderProjectList() string {
+	return m.projectList(m.width-8, m.height-8, m.state.SelectedIndex)
+}
+
+// projectList renders the projects in a list of the given size with the
+// project at selected highlighted
+func (m *Model) projectList(width, height, selected int) string {
 	if len(m.state.Projects) == 0 {
 		return lipgloss.NewStyle().
-			Width(m.width).
+			Width(width).
 			Padding(2, 2).
 			Render("No projects found")
 	}
@@ -215,11 +355,7 @@ func (m *Model) renderProjectList() string {
 			desc = "Archived"
 		}
 		if project.GroupID != "" {
-			groupID := project.GroupID
-			if len(groupID) > 4 {
-				groupID = groupID[len(groupID)-4:]
-			}
-			desc += " • Group: " + groupID
+			desc += " • Group: " + shortID(project.GroupID)
 		}
 
 		title := project.Name
@@ -241,7 +377,7 @@ func (m *Model) renderProjectList() string {
 	delegate.Styles.SelectedDesc = listSelectedDescStyle
 
 	// Reset the list height to fit the current model height
-	l := list.New(items, delegate, m.width-8, m.height-8)
+	l := list.New(items, delegate, width, height)
 	l.SetShowTitle(false)
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
@@ -252,12 +388,12 @@ func (m *Model) renderProjectList() string {
 	l.Paginator.InactiveDot = paginatorInactive
 
 	// Set selected index
-	if m.state.SelectedIndex < len(items) {
-		l.Select(m.state.SelectedIndex)
+	if selected < len(items) {
+		l.Select(selected)
 	}
 
 	return listStyle.
-		Width(m.width - 8).
+		Width(width).
 		Render(l.View())
 }
 
@@ -271,15 +407,27 @@ func (i projectItem) Description() string { return i.desc }
 func (i projectItem) FilterValue() string { return i.title }
 
 func (m *Model) renderTaskList() string {
-	if len(m.state.Tasks) == 0 {
+	return m.taskList(m.width-8, m.height-8, m.state.SelectedIndex)
+}
+
+// taskList renders the tasks of the current project in a list of the given
+// size with the task at selected highlighted
+func (m *Model) taskList(width, height, selected int) string {
+	return renderTasks(m.state.Tasks, width, height, selected)
+}
+
+// renderTasks renders tasks in a list of the given size with the task at
+// selected highlighted
+func renderTasks(tasks []models.Task, width, height, selected int) string {
+	if len(tasks) == 0 {
 		return lipgloss.NewStyle().
-			Width(m.width).
+			Width(width).
 			Padding(2, 2).
 			Render("No tasks found")
 	}
 
-	items := make([]list.Item, len(m.state.Tasks))
-	for i, task := range m.state.Tasks {
+	items := make([]list.Item, len(tasks))
+	for i, task := range tasks {
 		title := task.Title
 
 		if task.Priority != models.PriorityNone {
@@ -316,6 +464,9 @@ func (m *Model) renderTaskList() string {
 			}
 			desc += content
 		}
+		if isPending(task) {
+			desc = "Saving..."
+		}
 
 		items[i] = taskItem{
 			title: title,
@@ -329,7 +480,7 @@ func (m *Model) renderTaskList() string {
 	delegate.Styles.SelectedTitle = listSelectedTitleStyle
 	delegate.Styles.SelectedDesc = listSelectedDescStyle
 
-	l := list.New(items, delegate, m.width-8, m.height-8)
+	l := list.New(items, delegate, width, height)
 	l.SetShowTitle(false)
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
@@ -340,15 +491,58 @@ func (m *Model) renderTaskList() string {
 	l.Paginator.InactiveDot = paginatorInactive
 
 	// Set selected index
-	if m.state.SelectedIndex < len(items) {
-		l.Select(m.state.SelectedIndex)
+	if selected < len(items) {
+		l.Select(selected)
 	}
 
 	return listStyle.
-		Width(m.width - 8).
+		Width(width).
 		Render(l.View())
 }
 
+// renderSearch shows the search prompt and the matching tasks
+func (m *Model) renderSearch() string {
+	m.searchInput.Width = m.width - 12
+	prompt := lipgloss.NewStyle().Padding(0, 2).Render(m.searchInput.View())
+
+	var hint string
+	switch {
+	case m.searchInput.Value() == "" && m.searchTasks == nil:
+		hint = "Loading tasks..."
+	case m.searchInput.Value() == "":
+		hint = fmt.Sprintf("Search %d tasks by title, content, checklist items and tags", len(m.searchTasks))
+	case len(m.searchResults) == 0:
+		hint = "No matching tasks"
+	}
+	if hint != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, prompt, lipgloss.NewStyle().
+			Width(m.width).
+			Padding(1, 2).
+			Foreground(DARK_GRAY).
+			Render(hint))
+	}
+
+	items := make([]list.Item, len(m.searchResults))
+	for i, result := range m.searchResults {
+		items[i] = result
+	}
+	l := list.New(items, searchDelegate{}, m.width-8, m.height-10)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+
+	l.Paginator.Type = paginator.Dots
+	l.Paginator.ActiveDot = paginatorActive
+	l.Paginator.InactiveDot = paginatorInactive
+
+	if m.state.SelectedIndex < len(items) {
+		l.Select(m.state.SelectedIndex)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, prompt, listStyle.Width(m.width-8).Render(l.View()))
+}
+
 type taskItem struct {
 	title, desc string
 }
@@ -357,75 +551,461 @@ func (i taskItem) Title() string       { return i.title }
 func (i taskItem) Description() string { return i.desc }
 func (i taskItem) FilterValue() string { return i.title }
 
-func (m *Model) renderMessage() string {
-	if m.state.Error != "" {
-		return messageErrorStyle.Render(m.state.Error)
+// renderTaskForm shows the form for creating and editing tasks, with a
+// calendar next to it while a date is being picked
+func (m *Model) renderTaskForm() string {
+	title := "新建任务"
+	if m.editTask != nil {
+		title = "编辑任务"
 	}
-	if m.state.Message != "" {
-		return messageStyle.Render(m.state.Message)
+	return m.renderForm(title, taskFieldLabels)
+}
+
+// renderProjectForm shows the form for creating and editing projects
+func (m *Model) renderProjectForm() string {
+	title := "新建项目"
+	if m.editProject != nil {
+		title = "编辑项目"
 	}
-	return ""
+	return m.renderForm(title, projectFieldLabels)
 }
 
-func (m *Model) renderHelp() string {
-	if m.width == 0 {
+// renderForm shows the fields in CurrentItems with their labels and
+// validation errors
+func (m *Model) renderForm(title string, labels []string) string {
+	var form strings.Builder
+	var calendar string
+	allDay := false
+	for _, item := range m.state.CurrentItems {
+		if toggle, ok := item.(toggleField); ok {
+			allDay = toggle.on
+		}
+	}
+
+	for i, item := range m.state.CurrentItems {
+		focused := i == m.state.SelectedIndex
+		style := formBlurredStyle
+		if focused {
+			style = formFocusedStyle
+		}
+
+		var value string
+		switch v := item.(type) {
+		case textinput.Model:
+			// Leave room for the label and the calendar
+			v.Width = max(m.width-64, 20)
+			if focused {
+				v.TextStyle = formFocusedStyle
+				v.PromptStyle = formFocusedStyle
+				v.PlaceholderStyle = formFocusedPlaceHolderStyle
+				v.Cursor.Style = formFocusedStyle
+			} else {
+				v.TextStyle = formBlurredStyle
+				v.PromptStyle = formBlurredStyle
+				v.PlaceholderStyle = formBlurredPlaceHolderStyle
+			}
+			value = v.View()
+		case choiceField:
+			value = v.label()
+			if v.locked {
+				value += " (locked)"
+			} else if focused {
+				value = "‹ " + value + " ›"
+			}
+			value = style.Render(value)
+		case colorField:
+			value = renderSwatches(v, focused)
+		case toggleField:
+			value = "[ ]"
+			if v.on {
+				value = "[x]"
+			}
+			value = style.Render(value)
+		case dateField:
+			value = "-"
+			if v.set && allDay {
+				value = v.value.Format("2006-01-02 Mon")
+			} else if v.set {
+				value = v.value.Format("2006-01-02 Mon 15:04")
+			}
+			value = style.Render(value)
+			if focused {
+				calendar = renderCalendar(v)
+			}
+		}
+
+		form.WriteString(formLabelStyle.Render(labels[i]) + value + "\n")
+		if err := m.formErrors[i]; err != "" {
+			form.WriteString(formErrorStyle.Render(err) + "\n")
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		formTitleStyle.Render(title),
+		lipgloss.JoinHorizontal(lipgloss.Top, form.String(), calendar),
+	)
+
+	return formStyle.Padding(1, 4).Width(m.width - 8).Render(content)
+}
+
+// renderSwatches shows the colors of a color field in their color, with
+// the hex value of the selected one
+func renderSwatches(f colorField, focused bool) string {
+	var b strings.Builder
+	for i, color := range f.values {
+		swatch := "○"
+		if color != "" {
+			swatch = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render("●")
+		}
+		if i == f.selected {
+			swatch = "[" + swatch + "]"
+		} else {
+			swatch = " " + swatch + " "
+		}
+		b.WriteString(swatch)
+	}
+
+	label := f.label()
+	if focused {
+		label = formFocusedStyle.Render(label)
+	} else {
+		label = formBlurredStyle.Render(label)
+	}
+	return b.String() + " " + label
+}
+
+// renderCalendar shows the month of a date field with the picked day
+// highlighted
+func renderCalendar(f dateField) string {
+	now := time.Now().In(f.loc)
+	day := now
+	if f.set {
+		day = f.value
+	}
+	first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, f.loc)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(first.Format("January 2006")) + "\n")
+	b.WriteString(formBlurredStyle.Render("Mo Tu We Th Fr Sa Su") + "\n")
+	// Weeks start on Monday
+	b.WriteString(strings.Repeat("   ", (int(first.Weekday())+6)%7))
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		cell := fmt.Sprintf("%2d", d.Day())
+		switch {
+		case f.set && d.YearDay() == day.YearDay():
+			cell = formFocusedStyle.Render(cell)
+		case d.Year() == now.Year() && d.YearDay() == now.YearDay():
+			cell = calendarTodayStyle.Render(cell)
+		}
+		b.WriteString(cell)
+		if d.Weekday() == time.Sunday {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+
+	return calendarStyle.Render(strings.TrimRight(b.String(), "\n "))
+}
+
+// renderDeleteConfirm shows what is about to be deleted in a dialog
+func (m *Model) renderDeleteConfirm() string {
+	d := m.deleting
+	if d == nil {
 		return ""
 	}
 
-	var helpItems []string
+	var title, lost string
+	if d.task != nil {
+		title = "删除任务"
+		lost = "「" + d.task.Title + "」"
+		if n := len(d.task.Items); n > 0 {
+			lost += fmt.Sprintf("\n及其 %d 个清单项", n)
+		}
+	} else {
+		title = "删除项目"
+		lost = "「" + d.project.Name + "」"
+		switch {
+		case d.taskCount < 0:
+			lost += "\n" + m.spinner.View() + " 正在统计任务..."
+		case d.taskCount > 0:
+			lost += fmt.Sprintf("\n及其中的 %d 个任务", d.taskCount)
+		default:
+			lost += "\n项目中没有任务"
+		}
+	}
 
-	switch m.state.CurrentView {
-	case models.ConfigView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", ""),
-			m.helpKey("Up/Down", "Select"),
-			m.helpKey("Enter", ""),
+	dialog := dialogStyle.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		dialogTitleStyle.Render(title),
+		lost,
+		"",
+		formBlurredStyle.Render(fmt.Sprintf("删除后 %d 秒内可按 %s 撤销，之后可通过 trash restore 恢复", undoSeconds, m.keyName(actionUndo))),
+		"",
+		m.helpKey(m.keyName(actionConfirm), "删除")+"  •  "+m.helpKey(m.keyName(actionCancel), "取消"),
+	))
+
+	return lipgloss.Place(m.width, m.height-4, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// renderTaskDetail shows every field of the current task in a scrollable
+// viewport
+func (m *Model) renderTaskDetail() string {
+	task := m.state.CurrentTask
+	if task == nil {
+		return ""
+	}
+
+	width := m.width - detailStyle.GetHorizontalPadding()
+	m.detail.Width = width
+	m.detail.Height = m.height - 4 - detailStyle.GetVerticalPadding()
+	m.detail.SetContent(taskDetail(*task, width))
+
+	return detailStyle.Render(m.detail.View())
+}
+
+// taskDetail renders the fields of a task, wrapped to width
+func taskDetail(task models.Task, width int) string {
+	var b strings.Builder
+
+	title := detailTitleStyle.Width(width)
+	if task.Status == models.StatusCompleted {
+		title = title.Strikethrough(true)
+	}
+	b.WriteString(title.Render(task.Title))
+	b.WriteString("\n\n")
+
+	field := func(label, value string) {
+		if value == "" {
+			return
 		}
-	case models.AuthView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
-			m.helpKey("Enter", "Submit"),
+		label = detailLabelStyle.Render(label)
+		value = lipgloss.NewStyle().Width(width - lipgloss.Width(label)).Render(value)
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, label, value))
+		b.WriteString("\n")
+	}
+
+	status := "Open"
+	if task.Status == models.StatusCompleted {
+		status = "Completed"
+		if task.CompletedTime != nil {
+			status += " " + task.CompletedTime.Time.Local().Format("2006-01-02 15:04")
 		}
-	case models.ProjectListView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
-			m.helpKey("Up/Down", "Select"),
-			m.helpKey("Enter", "Open"),
-			m.helpKey("a", "New"),
-			m.helpKey("d", "Delete"),
-			m.helpKey("e", "Edit"),
+	}
+	field("Status", status)
+
+	switch task.Priority {
+	case models.PriorityLow:
+		field("Priority", priorityLow)
+	case models.PriorityMedium:
+		field("Priority", priorityMedium)
+	case models.PriorityHigh:
+		field("Priority", priorityHigh)
+	default:
+		field("Priority", priorityNone)
+	}
+
+	if task.StartDate != nil {
+		field("Start", taskTime(task, task.StartDate))
+	}
+	if task.DueDate != nil {
+		field("Due", taskTime(task, task.DueDate))
+	}
+
+	reminders := make([]string, len(task.Reminders))
+	for i, reminder := range task.Reminders {
+		reminders[i] = formatReminder(reminder, task.IsAllDay)
+	}
+	field("Reminders", strings.Join(reminders, "\n"))
+	field("Repeat", formatRepeat(task.RepeatFlag))
+
+	tags := make([]string, len(task.Tags))
+	for i, tag := range task.Tags {
+		tags[i] = "#" + tag
+	}
+	field("Tags", strings.Join(tags, " "))
+
+	for _, text := range []string{task.Content, task.Desc} {
+		if strings.TrimSpace(text) != "" {
+			b.WriteString("\n")
+			b.WriteString(lipgloss.NewStyle().Width(width).Render(text))
+			b.WriteString("\n")
 		}
-	case models.TaskListView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
-			m.helpKey("Up/Down", "Select"),
-			m.helpKey("Enter", "Open"),
-			m.helpKey("a", "New"),
-			m.helpKey("d", "Delete"),
-			m.helpKey("e", "Edit"),
-			m.helpKey("Space", "[Un]Complete"),
-			m.helpKey("Esc", "Back"),
+	}
+
+	if len(task.Items) > 0 {
+		done := 0
+		for _, item := range task.Items {
+			if item.Status == models.ItemStatusCompleted {
+				done++
+			}
 		}
-	case models.TaskDetailView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
-			m.helpKey("e", "Edit"),
-			m.helpKey("d", "Delete"),
-			m.helpKey("Space", "[Un]Complete"),
-			m.helpKey("Esc", "Back"),
+		b.WriteString("\n")
+		b.WriteString(detailLabelStyle.UnsetWidth().Render(fmt.Sprintf("Checklist %d/%d", done, len(task.Items))))
+		b.WriteString("\n")
+
+		for _, item := range task.Items {
+			line := "[ ] " + item.Title
+			if item.Status == models.ItemStatusCompleted {
+				line = detailDoneStyle.Render("[x] " + item.Title)
+			}
+			b.WriteString(lipgloss.NewStyle().Width(width).Render(line))
+			b.WriteString("\n")
 		}
-	case models.DeleteConfirmView:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
-			m.helpKey("Enter", "Confirm"),
-			m.helpKey("Esc", "Back"),
+	}
+
+	return b.String()
+}
+
+// taskTime formats a date of the task in the task's time zone, adding the
+// local time if it differs
+func taskTime(task models.Task, t *models.TickTickTime) string {
+	loc := time.Local
+	if task.TimeZone != "" {
+		if l, err := time.LoadLocation(task.TimeZone); err == nil {
+			loc = l
+		}
+	}
+
+	in := t.Time.In(loc)
+	if task.IsAllDay {
+		return in.Format("2006-01-02 Mon") + " (all day)"
+	}
+
+	s := in.Format("2006-01-02 Mon 15:04")
+	if task.TimeZone != "" {
+		s += " " + task.TimeZone
+	}
+	local := t.Time.Local()
+	_, offset := in.Zone()
+	_, localOffset := local.Zone()
+	if offset != localOffset {
+		s += " (local " + local.Format("2006-01-02 15:04") + ")"
+	}
+	return s
+}
+
+// isoDuration matches the durations of reminder triggers, e.g. -P1DT9H
+var isoDuration = regexp.MustCompile(`^([+-]?)P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// formatReminder describes a reminder trigger such as TRIGGER:-PT15M.
+// Reminders of all-day tasks are relative to the start of the day.
+func formatReminder(reminder string, allDay bool) string {
+	offset, ok := reminderOffset(reminder)
+	if !ok {
+		return reminder
+	}
+
+	if allDay {
+		day := 24 * time.Hour
+		days := offset / day
+		if offset%day < 0 {
+			days--
 		}
+		at := offset - days*day
+		clock := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Add(at).Format("15:04")
+		switch {
+		case days == 0:
+			return "On the day at " + clock
+		case days < 0:
+			return fmt.Sprintf("%d day(s) before at %s", -days, clock)
+		default:
+			return fmt.Sprintf("%d day(s) after at %s", days, clock)
+		}
+	}
 
+	switch {
+	case offset == 0:
+		return "At due time"
+	case offset < 0:
+		return formatDuration(-offset) + " before"
 	default:
-		helpItems = []string{
-			m.helpKey("Ctrl+c", "Exit"),
+		return formatDuration(offset) + " after"
+	}
+}
+
+// formatDuration formats a duration as days, hours and minutes, e.g. 1d 2h
+func formatDuration(d time.Duration) string {
+	var parts []string
+	if days := d / (24 * time.Hour); days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+		d -= days * 24 * time.Hour
+	}
+	if hours := d / time.Hour; hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+var repeatUnits = map[string]string{
+	"DAILY":   "day",
+	"WEEKLY":  "week",
+	"MONTHLY": "month",
+	"YEARLY":  "year",
+}
+
+// formatRepeat describes a repeat flag such as RRULE:FREQ=WEEKLY;INTERVAL=2,
+// keeping rule parts it does not know as written
+func formatRepeat(flag string) string {
+	rule, ok := strings.CutPrefix(flag, "RRULE:")
+	if !ok {
+		return flag
+	}
+
+	unit, interval := "", 1
+	var rest []string
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			unit = repeatUnits[value]
+		case "INTERVAL":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				interval = n
+			}
+		case "":
+		default:
+			rest = append(rest, part)
 		}
 	}
+	if unit == "" {
+		return flag
+	}
+
+	s := "Every " + unit
+	if interval > 1 {
+		s = fmt.Sprintf("Every %d %ss", interval, unit)
+	}
+	if len(rest) > 0 {
+		s += " (" + strings.Join(rest, ";") + ")"
+	}
+	return s
+}
+
+func (m *Model) renderMessage() string {
+	if m.state.Error != "" {
+		return messageErrorStyle.Render(m.state.Error)
+	}
+	if m.state.Message != "" {
+		return messageStyle.Render(m.state.Message)
+	}
+	return ""
+}
+
+func (m *Model) renderHelp() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	var helpItems []string
+	for _, b := range m.helpBindings(m.keyHelp(), false) {
+		helpItems = append(helpItems, m.helpKey(b.Help().Key, b.Help().Desc))
+	}
 
 	// Join help items with separators
 	helpContent := strings.Join(helpItems, "  •  ")
@@ -444,6 +1024,21 @@ func (m *Model) renderHelp() string {
 	return helpStyle.Width(m.width).Render(helpContent)
 }
 
+// renderHelpOverlay lists all keys of the current view by category
+func (m *Model) renderHelpOverlay() string {
+	var sections []string
+	names, groups := m.helpGroups()
+	for i, name := range names {
+		sections = append(sections, helpCategoryStyle.Render(name)+"\n"+m.help.FullHelpView(groups[i:i+1]))
+	}
+
+	m.helpView.Width = m.width - detailStyle.GetHorizontalPadding()
+	m.helpView.Height = m.height - 4 - detailStyle.GetVerticalPadding()
+	m.helpView.SetContent(strings.Join(sections, "\n\n"))
+
+	return detailStyle.Render(m.helpView.View())
+}
+
 func (m *Model) helpKey(key, desc string) string {
 	return helpKeyStyle.Render(key) + " " + helpDescStyle.Render(desc)
 }